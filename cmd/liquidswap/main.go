@@ -6,7 +6,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
+	"io/ioutil"
 	"time"
 
 	"github.com/condensat/bank-core/appcontext"
@@ -16,10 +18,32 @@ import (
 	"github.com/condensat/bank-core/monitor/processus"
 
 	"github.com/condensat/bank-swap/liquid"
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/handlers"
+	"github.com/condensat/bank-swap/liquid/native"
 )
 
 type Swap struct {
-	ElementsConf string
+	ElementsConf     string
+	Backend          string
+	AssetRegistry    string
+	Wallet           string
+	Wallets          string
+	Network          string
+	ProposalTTL      time.Duration
+	PairTTLs         string
+	Confirmations    int
+	BroadcastTimeout time.Duration
+	BroadcastWindow  time.Duration
+	Timeout          time.Duration
+	Timeouts         string
+	QueueSize        int
+}
+
+// WalletConf is a wallets file entry
+type WalletConf struct {
+	Backend      string `json:"backend"`
+	ElementsConf string `json:"elementsConf"`
 }
 
 type Args struct {
@@ -39,7 +63,20 @@ func parseArgs() Args {
 	cache.OptionArgs(&args.Redis)
 	messaging.OptionArgs(&args.Nats)
 
-	flag.StringVar(&args.Swap.ElementsConf, "elementsConf", "/etc/liquidswap/elements.conf", "Elements conf file for RPC")
+	flag.StringVar(&args.Swap.ElementsConf, "elementsConf", handlers.DefaultElementsConf, "Elements conf file for RPC")
+	flag.StringVar(&args.Swap.Backend, "backend", "cli", "Swap backend, cli or native (default 'cli')")
+	flag.StringVar(&args.Swap.AssetRegistry, "assetRegistry", "", "Asset registry json file, for assets precision and tickers")
+	flag.StringVar(&args.Swap.Wallet, "wallet", common.DefaultWallet, "Default wallet name, shared by all instances using the same elements wallet")
+	flag.StringVar(&args.Swap.Network, "network", string(common.DefaultNetwork), "Liquid network, liquidv1, liquidtestnet or elementsregtest")
+	flag.DurationVar(&args.Swap.ProposalTTL, "proposalTTL", common.DefaultProposalTTL, "Default proposal time to live")
+	flag.StringVar(&args.Swap.PairTTLs, "pairTTLs", "", "Proposal time to live per asset pair, ex: USDt/LCAD=5m,L-BTC/USDt=2m")
+	flag.IntVar(&args.Swap.Confirmations, "confirmations", handlers.DefaultConfirmations, "Confirmations count for confirmed swaps")
+	flag.DurationVar(&args.Swap.BroadcastTimeout, "broadcastTimeout", handlers.DefaultBroadcastTimeout, "Delay before an unconfirmed swap transaction is failed as dropped")
+	flag.DurationVar(&args.Swap.BroadcastWindow, "broadcastWindow", handlers.DefaultBroadcastWindow, "Delay to broadcast a swap finalized without broadcast, before its unspents are released")
+	flag.DurationVar(&args.Swap.Timeout, "timeout", common.DefaultRequestTimeout, "Default timeout for swap operations, including queue wait and wallet lock")
+	flag.StringVar(&args.Swap.Timeouts, "timeouts", "", "Timeout per swap operation, ex: CreateProposal=30s,Balances=5s")
+	flag.IntVar(&args.Swap.QueueSize, "queueSize", handlers.DefaultQueueCapacity, "Waiting requests per wallet before busy replies")
+	flag.StringVar(&args.Swap.Wallets, "wallets", "", "Wallets json file, wallet name to backend and elementsConf")
 
 	flag.Parse()
 
@@ -56,6 +93,115 @@ func main() {
 	ctx = appcontext.WithMessaging(ctx, messaging.NewNats(ctx, args.Nats))
 	ctx = appcontext.WithProcessusGrabber(ctx, processus.NewGrabber(ctx, 15*time.Second))
 
+	network, err := common.ParseNetwork(args.Swap.Network)
+	if err != nil {
+		logger.Logger(ctx).WithError(err).
+			WithField("Network", args.Swap.Network).
+			Panic("Invalid network")
+	}
+	ctx = common.NetworkContext(ctx, network)
+
+	loadAssetRegistry(ctx, args.Swap.AssetRegistry)
+
+	proposalTTL, err := common.ParseProposalTTL(args.Swap.ProposalTTL, args.Swap.PairTTLs, common.DefaultAssetRegistry())
+	if err != nil {
+		logger.Logger(ctx).WithError(err).
+			WithField("PairTTLs", args.Swap.PairTTLs).
+			Panic("Invalid proposal TTL")
+	}
+	ctx = handlers.ProposalTTLContext(ctx, proposalTTL)
+	ctx = handlers.SwapConfirmationsContext(ctx, args.Swap.Confirmations)
+	ctx = handlers.BroadcastTimeoutContext(ctx, args.Swap.BroadcastTimeout)
+	ctx = handlers.BroadcastWindowContext(ctx, args.Swap.BroadcastWindow)
+
+	timeouts, err := common.ParseRequestTimeouts(args.Swap.Timeout, args.Swap.Timeouts)
+	if err != nil {
+		logger.Logger(ctx).WithError(err).
+			WithField("Timeouts", args.Swap.Timeouts).
+			Panic("Invalid request timeouts")
+	}
+	ctx = common.RequestTimeoutsContext(ctx, timeouts)
+	ctx = handlers.WorkQueueContext(ctx, handlers.NewWorkQueue(args.Swap.QueueSize))
+
 	var swap liquid.Swap
-	swap.Run(ctx, args.Swap.ElementsConf)
+	swap.Run(ctx, swapWallets(ctx, args.Swap))
+}
+
+func swapWallets(ctx context.Context, args Swap) *handlers.WalletRegistry {
+	log := logger.Logger(ctx).WithField("Method", "main.swapWallets")
+
+	wallets := handlers.NewWalletRegistry(args.Wallet)
+	wallets.Register(args.Wallet, swapBackend(ctx, args.Backend, args.ElementsConf))
+
+	if len(args.Wallets) == 0 {
+		return wallets
+	}
+
+	data, err := ioutil.ReadFile(args.Wallets)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallets", args.Wallets).
+			Panic("Failed to read wallets file")
+	}
+	var confs map[string]WalletConf
+	err = json.Unmarshal(data, &confs)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallets", args.Wallets).
+			Panic("Invalid wallets file")
+	}
+
+	for wallet, conf := range confs {
+		if len(conf.Backend) == 0 {
+			conf.Backend = args.Backend
+		}
+		wallets.Register(wallet, swapBackend(ctx, conf.Backend, conf.ElementsConf))
+	}
+
+	return wallets
+}
+
+func swapBackend(ctx context.Context, backendName, elementsConf string) common.SwapBackend {
+	log := logger.Logger(ctx).WithField("Method", "main.swapBackend")
+
+	switch backendName {
+	case "cli":
+		backend, err := handlers.NewCliBackend(elementsConf)
+		if err != nil {
+			log.WithError(err).
+				WithField("ElementsConf", elementsConf).
+				Panic("Failed to create cli backend")
+		}
+		return backend
+
+	case "native":
+		backend, err := native.NewBackend(elementsConf)
+		if err != nil {
+			log.WithError(err).
+				WithField("ElementsConf", elementsConf).
+				Panic("Failed to create native backend")
+		}
+		return backend
+
+	default:
+		log.WithField("Backend", backendName).
+			Panic("Unknown swap backend")
+		return nil
+	}
+}
+
+func loadAssetRegistry(ctx context.Context, filename string) {
+	log := logger.Logger(ctx).WithField("Method", "main.loadAssetRegistry")
+
+	registry := common.NewNetworkAssetRegistry(common.NetworkFromContext(ctx))
+	if len(filename) > 0 {
+		assets, err := common.LoadAssetRegistry(filename)
+		if err != nil {
+			log.WithError(err).
+				WithField("AssetRegistry", filename).
+				Panic("Failed to load asset registry")
+		}
+		registry.Merge(assets)
+	}
+	common.SetAssetRegistry(registry)
 }
@@ -22,9 +22,9 @@ func main() {
 	proposal, err := client.CreateSwapProposal(ctx, 42,
 		address, common.ProposalInfo{
 			ProposerAsset:  "ce091c998b83c78bb71a632313ba3760f1763d9cfcffae02258ffa9865a37bd2", // USDt
-			ProposerAmount: 1000 / 100000000.0,
+			ProposerAmount: 1000,
 			ReceiverAsset:  "0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a", // LCAD
-			ReceiverAmount: 1400 / 100000000.0,
+			ReceiverAmount: 1400,
 		},
 		common.DefaultFeeRate,
 	)
@@ -45,6 +45,9 @@ func main() {
 			panic(common.ErrInvalidPayload)
 		}
 		log.Printf("Info: %+v", info)
+		if info.Info != nil {
+			log.Printf("SwapInfo: %+v", *info.Info)
+		}
 	}
 
 	if proposal, err := client.FinalizeSwapProposal(ctx, 42, payload); true {
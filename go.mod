@@ -4,9 +4,10 @@ go 1.14
 
 require (
 	github.com/condensat/bank-core v0.0.3-0.20200513090000-d1dfff7e3329
+	github.com/go-redis/redis/v7 v7.2.0
 	github.com/google/uuid v1.1.2 // indirect
 	github.com/jinzhu/gorm v1.9.16 // indirect
-	github.com/nats-io/nats.go v1.10.0 // indirect
+	github.com/nats-io/nats.go v1.10.0
 	github.com/sirupsen/logrus v1.7.0
 	golang.org/x/crypto v0.0.0-20201012173705-84dcc777aaee // indirect
 	google.golang.org/protobuf v1.25.0 // indirect
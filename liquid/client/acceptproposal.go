@@ -8,33 +8,39 @@ import (
 	"context"
 
 	"github.com/condensat/bank-core/logger"
-	"github.com/condensat/bank-core/messaging"
 
 	"github.com/condensat/bank-swap/liquid/common"
 
 	"github.com/sirupsen/logrus"
 )
 
-func AcceptSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, payload common.Payload, feeRate float64) (common.SwapProposal, error) {
+func AcceptSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, payload common.Payload, feeRate common.Amount) (common.AcceptProposalResponse, error) {
 	log := logger.Logger(ctx).WithField("Method", "Liquid.client.AcceptSwapProposal")
 
-	if !payload.Valid() {
-		return common.SwapProposal{}, common.ErrInvalidPayload
-	}
-
-	request := common.SwapProposal{
+	request := common.AcceptProposalRequest{
+		Version: common.SchemaVersionFromContext(ctx),
 		SwapID:  swapID,
 		Address: address,
 		Payload: payload,
 		FeeRate: feeRate,
+		Wallet:  common.SwapWalletFromContext(ctx),
+	}
+	if err := request.Validate(common.NetworkFromContext(ctx)); err != nil {
+		return common.AcceptProposalResponse{}, err
 	}
 
-	var result common.SwapProposal
-	err := messaging.RequestMessage(ctx, common.SwapAcceptProposalSubject, &request, &result)
+	var result common.AcceptProposalResponse
+	err := requestMessage(ctx, common.SwapAcceptProposalSubject, &request, &result)
 	if err != nil {
 		log.WithError(err).
 			Error("RequestMessage failed")
-		return common.SwapProposal{}, messaging.ErrRequestFailed
+		return common.AcceptProposalResponse{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.AcceptProposalResponse{}, result.Error
 	}
 
 	log.WithFields(logrus.Fields{
@@ -0,0 +1,46 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SwapBalances return wallet spendable and reserved balances by asset
+func SwapBalances(ctx context.Context) (common.SwapBalances, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.client.SwapBalances")
+
+	request := common.SwapBalances{
+		Version: common.SchemaVersionFromContext(ctx),
+		Wallet:  common.SwapWalletFromContext(ctx),
+	}
+
+	var result common.SwapBalances
+	err := requestMessage(ctx, common.SwapBalancesSubject, &request, &result)
+	if err != nil {
+		log.WithError(err).
+			Error("RequestMessage failed")
+		return common.SwapBalances{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.SwapBalances{}, result.Error
+	}
+
+	log.WithFields(logrus.Fields{
+		"Wallet":   result.Wallet,
+		"Balances": len(result.Balances),
+	}).Debug("Swap Balances")
+
+	return result, nil
+}
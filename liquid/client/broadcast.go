@@ -0,0 +1,56 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BroadcastSwapTransaction send the transaction returned by FinalizeSwapProposalWithoutBroadcast
+func BroadcastSwapTransaction(ctx context.Context, swapID uint64, payload common.Payload) (common.BroadcastResponse, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.client.BroadcastSwapTransaction")
+
+	request := common.BroadcastRequest{
+		Version: common.SchemaVersionFromContext(ctx),
+		SwapID:  swapID,
+		Payload: payload,
+		Wallet:  common.SwapWalletFromContext(ctx),
+	}
+	if err := request.Validate(common.NetworkFromContext(ctx)); err != nil {
+		return common.BroadcastResponse{}, err
+	}
+
+	var result common.BroadcastResponse
+	err := requestMessage(ctx, common.SwapBroadcastSubject, &request, &result)
+	if err != nil {
+		log.WithError(err).
+			Error("RequestMessage failed")
+		return common.BroadcastResponse{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.BroadcastResponse{}, result.Error
+	}
+
+	var txID string
+	if finalized, err := result.Payload.Finalized(); err == nil {
+		txID = finalized.TxID
+	}
+
+	log.WithFields(logrus.Fields{
+		"SwapID": result.SwapID,
+		"TxID":   txID,
+	}).Debug("Broadcast SwapTransaction")
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CancelSwapProposal spend the proposal inputs back to the proposer wallet.
+// The cancelling transaction id is available from the result payload.
+func CancelSwapProposal(ctx context.Context, swapID uint64, payload common.Payload, feeRate common.Amount) (common.CancelProposalResponse, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.client.CancelSwapProposal")
+
+	request := common.CancelProposalRequest{
+		Version: common.SchemaVersionFromContext(ctx),
+		SwapID:  swapID,
+		Payload: payload,
+		FeeRate: feeRate,
+		Wallet:  common.SwapWalletFromContext(ctx),
+	}
+	if err := request.Validate(common.NetworkFromContext(ctx)); err != nil {
+		return common.CancelProposalResponse{}, err
+	}
+
+	var result common.CancelProposalResponse
+	err := requestMessage(ctx, common.SwapCancelProposalSubject, &request, &result)
+	if err != nil {
+		log.WithError(err).
+			Error("RequestMessage failed")
+		return common.CancelProposalResponse{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.CancelProposalResponse{}, result.Error
+	}
+
+	var cancelTxID string
+	if cancelled, err := result.Payload.Cancelled(); err == nil {
+		cancelTxID = cancelled.CancelTxID
+	}
+
+	log.WithFields(logrus.Fields{
+		"SwapID":     result.SwapID,
+		"CancelTxID": cancelTxID,
+	}).Debug("Cancel SwapProposal")
+
+	return result, nil
+}
@@ -8,36 +8,39 @@ import (
 	"context"
 
 	"github.com/condensat/bank-core/logger"
-	"github.com/condensat/bank-core/messaging"
 
 	"github.com/condensat/bank-swap/liquid/common"
 
 	"github.com/sirupsen/logrus"
 )
 
-func CreateSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate float64) (common.SwapProposal, error) {
+func CreateSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) (common.CreateProposalResponse, error) {
 	log := logger.Logger(ctx).WithField("Method", "Liquid.client.CreateSwapProposal")
 
-	if len(address) == 0 {
-		return common.SwapProposal{}, common.ErrInvalidProposal
-	}
-	if !proposal.Valid() {
-		return common.SwapProposal{}, common.ErrInvalidProposal
-	}
-
-	request := common.SwapProposal{
+	request := common.CreateProposalRequest{
+		Version:  common.SchemaVersionFromContext(ctx),
 		SwapID:   swapID,
 		Address:  address,
 		Proposal: proposal,
 		FeeRate:  feeRate,
+		Wallet:   common.SwapWalletFromContext(ctx),
+	}
+	if err := request.Validate(common.NetworkFromContext(ctx)); err != nil {
+		return common.CreateProposalResponse{}, err
 	}
 
-	var result common.SwapProposal
-	err := messaging.RequestMessage(ctx, common.SwapCreateProposalSubject, &request, &result)
+	var result common.CreateProposalResponse
+	err := requestMessage(ctx, common.SwapCreateProposalSubject, &request, &result)
 	if err != nil {
 		log.WithError(err).
 			Error("RequestMessage failed")
-		return common.SwapProposal{}, messaging.ErrRequestFailed
+		return common.CreateProposalResponse{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.CreateProposalResponse{}, result.Error
 	}
 
 	log.WithFields(logrus.Fields{
@@ -0,0 +1,92 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/appcontext"
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrSubscribeFailed = errors.New("Subscribe Failed")
+)
+
+// SwapEventHandler is called for each received swap event
+type SwapEventHandler func(ctx context.Context, event common.SwapEvent)
+
+// SubscribeSwapEvents call handler for swap events in states, all states if none is given.
+// Each subscriber receive all events, subscriptions are closed when ctx is done.
+func SubscribeSwapEvents(ctx context.Context, handler SwapEventHandler, states ...string) error {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.client.SubscribeSwapEvents")
+
+	if len(states) == 0 {
+		states = []string{"*"}
+	}
+
+	// bank-core Subscribe is a queue subscription, events must not be shared between subscribers
+	messaging := appcontext.Messaging(ctx)
+	if messaging == nil {
+		return ErrSubscribeFailed
+	}
+	nc, ok := messaging.NC().(*nats.Conn)
+	if !ok || nc == nil {
+		return ErrSubscribeFailed
+	}
+
+	var subscriptions []*nats.Subscription
+	for _, state := range states {
+		subject := common.RequestSubject(ctx, common.SwapEventStateSubject(state))
+		subscription, err := nc.Subscribe(subject, func(msg *nats.Msg) {
+			message := new(bank.Message)
+			err := message.Decode(msg.Data)
+			var event common.SwapEvent
+			if err == nil {
+				err = bank.FromMessage(message, &event)
+			}
+			if err != nil {
+				log.WithError(err).
+					WithField("Subject", msg.Subject).
+					Error("Invalid swap event")
+				return
+			}
+
+			log.WithFields(logrus.Fields{
+				"SwapID": event.SwapID,
+				"State":  event.State,
+			}).Debug("Swap event")
+
+			handler(ctx, event)
+		})
+		if err != nil {
+			log.WithError(err).
+				WithField("Subject", subject).
+				Error("Failed to subscribe")
+			unsubscribe(subscriptions)
+			return ErrSubscribeFailed
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	go func() {
+		<-ctx.Done()
+		unsubscribe(subscriptions)
+	}()
+	return nil
+}
+
+func unsubscribe(subscriptions []*nats.Subscription) {
+	for _, subscription := range subscriptions {
+		_ = subscription.Unsubscribe()
+	}
+}
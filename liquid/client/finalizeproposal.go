@@ -8,35 +8,55 @@ import (
 	"context"
 
 	"github.com/condensat/bank-core/logger"
-	"github.com/condensat/bank-core/messaging"
 
 	"github.com/condensat/bank-swap/liquid/common"
 
 	"github.com/sirupsen/logrus"
 )
 
-func FinalizeSwapProposal(ctx context.Context, swapID uint64, payload common.Payload) (common.SwapProposal, error) {
-	log := logger.Logger(ctx).WithField("Method", "Liquid.client.FinalizeSwapProposal")
+// FinalizeSwapProposal sign and send the swap transaction
+func FinalizeSwapProposal(ctx context.Context, swapID uint64, payload common.Payload) (common.FinalizeProposalResponse, error) {
+	return finalizeSwapProposal(ctx, swapID, payload, false)
+}
 
-	if !payload.Valid() {
-		return common.SwapProposal{}, common.ErrInvalidPayload
-	}
+// FinalizeSwapProposalWithoutBroadcast return the signed transaction and txid without sending it.
+// Use BroadcastSwapTransaction with the result payload to send the transaction.
+func FinalizeSwapProposalWithoutBroadcast(ctx context.Context, swapID uint64, payload common.Payload) (common.FinalizeProposalResponse, error) {
+	return finalizeSwapProposal(ctx, swapID, payload, true)
+}
 
-	request := common.SwapProposal{
+func finalizeSwapProposal(ctx context.Context, swapID uint64, payload common.Payload, skipBroadcast bool) (common.FinalizeProposalResponse, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.client.FinalizeSwapProposal")
+
+	request := common.FinalizeProposalRequest{
+		Version: common.SchemaVersionFromContext(ctx),
 		SwapID:  swapID,
 		Payload: payload,
+		Wallet:  common.SwapWalletFromContext(ctx),
+
+		SkipBroadcast: skipBroadcast,
+	}
+	if err := request.Validate(common.NetworkFromContext(ctx)); err != nil {
+		return common.FinalizeProposalResponse{}, err
 	}
 
-	var result common.SwapProposal
-	err := messaging.RequestMessage(ctx, common.SwapFinalizeProposalSubject, &request, &result)
+	var result common.FinalizeProposalResponse
+	err := requestMessage(ctx, common.SwapFinalizeProposalSubject, &request, &result)
 	if err != nil {
 		log.WithError(err).
 			Error("RequestMessage failed")
-		return common.SwapProposal{}, messaging.ErrRequestFailed
+		return common.FinalizeProposalResponse{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.FinalizeProposalResponse{}, result.Error
 	}
 
 	log.WithFields(logrus.Fields{
-		"SwapID": result.SwapID,
+		"SwapID":        result.SwapID,
+		"SkipBroadcast": skipBroadcast,
 	}).Debug("Finaize SwapProposal")
 
 	return result, nil
@@ -8,31 +8,37 @@ import (
 	"context"
 
 	"github.com/condensat/bank-core/logger"
-	"github.com/condensat/bank-core/messaging"
 
 	"github.com/condensat/bank-swap/liquid/common"
 
 	"github.com/sirupsen/logrus"
 )
 
-func InfoSwapProposal(ctx context.Context, swapID uint64, payload common.Payload) (common.SwapProposal, error) {
+func InfoSwapProposal(ctx context.Context, swapID uint64, payload common.Payload) (common.InfoProposalResponse, error) {
 	log := logger.Logger(ctx).WithField("Method", "Liquid.client.SwapInfo")
 
-	if !payload.Valid() {
-		return common.SwapProposal{}, common.ErrInvalidPayload
-	}
-
-	request := common.SwapProposal{
+	request := common.InfoProposalRequest{
+		Version: common.SchemaVersionFromContext(ctx),
 		SwapID:  swapID,
 		Payload: payload,
+		Wallet:  common.SwapWalletFromContext(ctx),
+	}
+	if err := request.Validate(common.NetworkFromContext(ctx)); err != nil {
+		return common.InfoProposalResponse{}, err
 	}
 
-	var result common.SwapProposal
-	err := messaging.RequestMessage(ctx, common.SwapInfoProposalSubject, &request, &result)
+	var result common.InfoProposalResponse
+	err := requestMessage(ctx, common.SwapInfoProposalSubject, &request, &result)
 	if err != nil {
 		log.WithError(err).
 			Error("RequestMessage failed")
-		return common.SwapProposal{}, messaging.ErrRequestFailed
+		return common.InfoProposalResponse{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.InfoProposalResponse{}, result.Error
 	}
 
 	log.WithFields(logrus.Fields{
@@ -0,0 +1,45 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SwapQueueStatus return the swap service work queues depth and wait times
+func SwapQueueStatus(ctx context.Context) (common.SwapQueueStatus, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.client.SwapQueueStatus")
+
+	request := common.SwapQueueStatus{
+		Version: common.SchemaVersionFromContext(ctx),
+	}
+
+	var result common.SwapQueueStatus
+	err := requestMessage(ctx, common.SwapQueueStatusSubject, &request, &result)
+	if err != nil {
+		log.WithError(err).
+			Error("RequestMessage failed")
+		return common.SwapQueueStatus{}, err
+	}
+	if result.Error != nil {
+		log.WithError(result.Error).
+			WithField("Code", result.Error.Code).
+			Error("Swap request failed")
+		return common.SwapQueueStatus{}, result.Error
+	}
+
+	log.WithFields(logrus.Fields{
+		"Hostname": result.Hostname,
+		"Queues":   len(result.Queues),
+	}).Debug("Swap Queue Status")
+
+	return result, nil
+}
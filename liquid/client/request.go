@@ -0,0 +1,59 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/appcontext"
+	"github.com/condensat/bank-core/logger"
+	"github.com/condensat/bank-core/messaging"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+// requestTimeout return the operation timeout with margin for the handler reply,
+// limited by the ctx deadline
+func requestTimeout(ctx context.Context, subject string) time.Duration {
+	timeout := common.RequestTimeoutsFromContext(ctx).Timeout(subject) + common.RequestTimeoutMargin
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	return timeout
+}
+
+// requestMessage send request to the subject for the context network and schema version.
+// return ErrRequestTimeout if no reply is received before the operation timeout
+func requestMessage(ctx context.Context, subject string, request, response bank.BankObject) error {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.client.requestMessage")
+
+	timeout := requestTimeout(ctx, subject)
+	if timeout <= 0 {
+		return common.ErrRequestTimeout
+	}
+
+	nats := appcontext.Messaging(ctx)
+	message := bank.ToMessage(appcontext.AppName(ctx), request)
+
+	start := time.Now()
+	message, err := nats.RequestWithTimeout(ctx, common.RequestSubject(ctx, subject), message, timeout)
+	if err != nil {
+		if time.Since(start) >= timeout {
+			log.WithError(err).
+				WithField("Timeout", timeout).
+				Error("Request timeout")
+			return common.ErrRequestTimeout
+		}
+		return messaging.ErrRequestFailed
+	}
+
+	err = bank.FromMessage(message, response)
+	if err != nil {
+		return messaging.ErrRequestFailed
+	}
+	return nil
+}
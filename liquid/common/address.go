@@ -0,0 +1,291 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	BlindingKeyLength = 33
+
+	blech32Charset    = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	blech32Checksum   = 12
+	blech32Const      = 1
+	blech32mConst     = 0x455972a3350f7a1
+	blech32MaxLength  = 1000
+	base58Alphabet    = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base58Checksum    = 4
+	base58HashLength  = 20
+	base58AddressSize = 2 + BlindingKeyLength + base58HashLength
+)
+
+var (
+	ErrInvalidChecksum       = errors.New("Invalid Address Checksum")
+	ErrWrongAddressNetwork   = errors.New("Wrong Address Network")
+	ErrUnconfidentialAddress = errors.New("Unconfidential Address")
+
+	blech32Generator = [5]uint64{0x7d52fba40bd886, 0x5e8dbf1a03950c, 0x1c3a3c74072a18, 0x385d72fa0e5139, 0x7093e5a608865b}
+)
+
+// AddressInfo is a decoded confidential address
+type AddressInfo struct {
+	Network        Network
+	BlindingKey    []byte
+	WitnessVersion int // -1 for base58 addresses
+	Program        []byte
+}
+
+// Decode validate checksum and blinding key and return the address network
+func (p ConfidentialAddress) Decode() (AddressInfo, error) {
+	address := string(p)
+	if len(address) == 0 {
+		return AddressInfo{}, ErrInvalidAddress
+	}
+
+	if strings.Contains(address, "1") {
+		lower := strings.ToLower(address)
+		for _, params := range networks {
+			if strings.HasPrefix(lower, params.Bech32HRP+"1") {
+				return AddressInfo{}, ErrUnconfidentialAddress
+			}
+			if strings.HasPrefix(lower, params.Blech32HRP+"1") {
+				return decodeBlech32Address(params, address)
+			}
+		}
+	}
+
+	return decodeBase58Address(address)
+}
+
+// Validate return an error if the address is not a valid confidential address for network
+func (p ConfidentialAddress) Validate(network Network) error {
+	info, err := p.Decode()
+	if err != nil {
+		return err
+	}
+	if info.Network != network.Params().Network {
+		return ErrWrongAddressNetwork
+	}
+	return nil
+}
+
+func (p ConfidentialAddress) Valid(network Network) bool {
+	return p.Validate(network) == nil
+}
+
+// NewConfidentialAddress encode a blech32 segwit confidential address
+func NewConfidentialAddress(network Network, blindingKey []byte, witnessVersion int, program []byte) (ConfidentialAddress, error) {
+	if !validBlindingKey(blindingKey) || witnessVersion < 0 || witnessVersion > 16 || len(program) < 2 || len(program) > 40 {
+		return "", ErrInvalidAddress
+	}
+	hrp := network.Params().Blech32HRP
+
+	data := []byte{byte(witnessVersion)}
+	data = append(data, convertBits(append(append([]byte{}, blindingKey...), program...), 8, 5, true)...)
+
+	values := append(blech32HrpExpand(hrp), data...)
+	values = append(values, make([]byte, blech32Checksum)...)
+	mod := blech32Polymod(values) ^ blech32ChecksumConst(witnessVersion)
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, value := range data {
+		sb.WriteByte(blech32Charset[value])
+	}
+	for i := 0; i < blech32Checksum; i++ {
+		sb.WriteByte(blech32Charset[(mod>>uint(5*(blech32Checksum-1-i)))&31])
+	}
+	return ConfidentialAddress(sb.String()), nil
+}
+
+func decodeBlech32Address(params NetworkParams, address string) (AddressInfo, error) {
+	if len(address) > blech32MaxLength {
+		return AddressInfo{}, ErrInvalidAddress
+	}
+	// mixed case is not allowed
+	lower := strings.ToLower(address)
+	if lower != address && strings.ToUpper(address) != address {
+		return AddressInfo{}, ErrInvalidAddress
+	}
+
+	hrp := params.Blech32HRP
+	encoded := lower[len(hrp)+1:]
+	if len(encoded) < blech32Checksum+1 {
+		return AddressInfo{}, ErrInvalidAddress
+	}
+
+	var data []byte
+	for _, c := range encoded {
+		value := strings.IndexRune(blech32Charset, c)
+		if value < 0 {
+			return AddressInfo{}, ErrInvalidAddress
+		}
+		data = append(data, byte(value))
+	}
+
+	witnessVersion := int(data[0])
+	if witnessVersion > 16 {
+		return AddressInfo{}, ErrInvalidAddress
+	}
+	if blech32Polymod(append(blech32HrpExpand(hrp), data...)) != blech32ChecksumConst(witnessVersion) {
+		return AddressInfo{}, ErrInvalidChecksum
+	}
+
+	payload := convertBits(data[1:len(data)-blech32Checksum], 5, 8, false)
+	if payload == nil || len(payload) < BlindingKeyLength+2 || len(payload) > BlindingKeyLength+40 {
+		return AddressInfo{}, ErrInvalidAddress
+	}
+	blindingKey := payload[:BlindingKeyLength]
+	program := payload[BlindingKeyLength:]
+	if !validBlindingKey(blindingKey) {
+		return AddressInfo{}, ErrUnconfidentialAddress
+	}
+	if witnessVersion == 0 && len(program) != 20 && len(program) != 32 {
+		return AddressInfo{}, ErrInvalidAddress
+	}
+
+	return AddressInfo{
+		Network:        params.Network,
+		BlindingKey:    blindingKey,
+		WitnessVersion: witnessVersion,
+		Program:        program,
+	}, nil
+}
+
+// base58 confidential address is prefix, address version, blinding key and hash
+var base58Versions = map[Network][3]byte{
+	// confidential prefix, p2pkh, p2sh
+	NetworkLiquid:          {12, 57, 39},
+	NetworkLiquidTestnet:   {23, 36, 19},
+	NetworkElementsRegtest: {4, 235, 75},
+}
+
+func decodeBase58Address(address string) (AddressInfo, error) {
+	data, err := base58CheckDecode(address)
+	if err != nil {
+		return AddressInfo{}, err
+	}
+
+	for network, versions := range base58Versions {
+		if data[0] != versions[0] {
+			if len(data) == 1+base58HashLength && (data[0] == versions[1] || data[0] == versions[2]) {
+				return AddressInfo{}, ErrUnconfidentialAddress
+			}
+			continue
+		}
+		if len(data) != base58AddressSize || (data[1] != versions[1] && data[1] != versions[2]) {
+			return AddressInfo{}, ErrInvalidAddress
+		}
+
+		blindingKey := data[2 : 2+BlindingKeyLength]
+		if !validBlindingKey(blindingKey) {
+			return AddressInfo{}, ErrUnconfidentialAddress
+		}
+		return AddressInfo{
+			Network:        network,
+			BlindingKey:    blindingKey,
+			WitnessVersion: -1,
+			Program:        data[2+BlindingKeyLength:],
+		}, nil
+	}
+
+	return AddressInfo{}, ErrInvalidAddress
+}
+
+func base58CheckDecode(address string) ([]byte, error) {
+	value := new(big.Int)
+	radix := big.NewInt(int64(len(base58Alphabet)))
+	for _, c := range address {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, ErrInvalidAddress
+		}
+		value.Mul(value, radix)
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+
+	var leadingZeros int
+	for leadingZeros < len(address) && address[leadingZeros] == base58Alphabet[0] {
+		leadingZeros++
+	}
+	data := append(make([]byte, leadingZeros), value.Bytes()...)
+	if len(data) <= base58Checksum {
+		return nil, ErrInvalidAddress
+	}
+
+	payload := data[:len(data)-base58Checksum]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:base58Checksum], data[len(data)-base58Checksum:]) {
+		return nil, ErrInvalidChecksum
+	}
+	return payload, nil
+}
+
+func validBlindingKey(key []byte) bool {
+	return len(key) == BlindingKeyLength && (key[0] == 0x02 || key[0] == 0x03)
+}
+
+func blech32ChecksumConst(witnessVersion int) uint64 {
+	if witnessVersion == 0 {
+		return blech32Const
+	}
+	return blech32mConst
+}
+
+func blech32Polymod(values []byte) uint64 {
+	chk := uint64(1)
+	for _, value := range values {
+		top := chk >> 55
+		chk = (chk&0x7fffffffffffff)<<5 ^ uint64(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= blech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func blech32HrpExpand(hrp string) []byte {
+	var result []byte
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]>>5)
+	}
+	result = append(result, 0)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]&31)
+	}
+	return result
+}
+
+// convertBits regroup bits, return nil for invalid padding
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var acc, bits uint
+	var result []byte
+	maxValue := uint(1)<<toBits - 1
+	for _, value := range data {
+		acc = acc<<fromBits | uint(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			result = append(result, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+const (
+	testAddressLiquid  = ConfidentialAddress("lq1qqv8ymngmdp5yj2jukdd78ujm92m0wjvk7yxplra2haf5dnuzsutz96dvvqscm0raftaljf9p30wg4sd2alht5epuyn2fe7vn6")
+	testAddressTestnet = ConfidentialAddress("tlq1qq2p9fsef4y59pak488wnwm6gzmhzwez30kj7qg64zjh5xvtyfqxh59yda8z60fzdr8jkekdwrf25hancg7hmq49h6u682pdm6")
+	testAddressRegtest = ConfidentialAddress("el1qq2p9fsef4y59pak488wnwm6gzmhzwez30kj7qg64zjh5xvtyfqxh4r34ctxnhanyr0dsugzska5n9jajucp55rw6esweh65z56a90a70ylv32meenx2e")
+	testAddressTaproot = ConfidentialAddress("lq1pq2p9fsef4y59pak488wnwm6gzmhzwez30kj7qg64zjh5xvtyfqxh532rf8jz9uzjjuv3atgnugwnmdfquk4775s9teykfwp0kgfltyapr5fj9glcs9ld")
+
+	testBase58Liquid     = ConfidentialAddress("VTpwZNBePn3pMKrUZhWE1BjVskhwtmj6RnetPYaTrY8wR2eokMC51R6u7GxAKEQLhE7Eyf5SQf4DMGUH")
+	testBase58LiquidP2SH = ConfidentialAddress("VJL95WNrwnvdMa8qDCUjWwXWoYXztERCGtxUbPHDFsTYndiw5pe9sMNvinuRmTKEoW6FXaexdHjamhzL")
+	testBase58Testnet    = ConfidentialAddress("vtSA2mGRuS265eQ6ArL6qtNa6EEGqbFtLrg38t3NtRUuX5fH7akPYPJakFbxcSZW7fCpjVivoqtbSioC")
+	testBase58Regtest    = ConfidentialAddress("AzpnVxtARUr6wYKjUpGirtskFCURqtp22bkAy2FkDzf3yn7xcW2BtBA2wrJYJZUzg5jkq4NJkYWFHRQB")
+)
+
+func TestConfidentialAddress_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		address ConfidentialAddress
+		network Network
+		wantErr error
+	}{
+		{"liquid", testAddressLiquid, NetworkLiquid, nil},
+		{"liquidUpper", "LQ1QQV8YMNGMDP5YJ2JUKDD78UJM92M0WJVK7YXPLRA2HAF5DNUZSUTZ96DVVQSCM0RAFTALJF9P30WG4SD2ALHT5EPUYN2FE7VN6", NetworkLiquid, nil},
+		{"testnet", testAddressTestnet, NetworkLiquidTestnet, nil},
+		{"regtest", testAddressRegtest, NetworkElementsRegtest, nil},
+		{"taproot", testAddressTaproot, NetworkLiquid, nil},
+		{"base58", testBase58Liquid, NetworkLiquid, nil},
+		{"base58P2SH", testBase58LiquidP2SH, NetworkLiquid, nil},
+		{"base58Testnet", testBase58Testnet, NetworkLiquidTestnet, nil},
+		{"base58Regtest", testBase58Regtest, NetworkElementsRegtest, nil},
+
+		{"empty", "", NetworkLiquid, ErrInvalidAddress},
+		{"garbage", "not-an-address", NetworkLiquid, ErrInvalidAddress},
+		{"mixedCase", "lq1QQV8YMNGMDP5YJ2JUKDD78UJM92M0WJVK7YXPLRA2HAF5DNUZSUTZ96DVVQSCM0RAFTALJF9P30WG4SD2ALHT5EPUYN2FE7VN6", NetworkLiquid, ErrInvalidAddress},
+		{"checksum", testAddressLiquid[:len(testAddressLiquid)-1] + "7", NetworkLiquid, ErrInvalidChecksum},
+		{"base58Checksum", testBase58Liquid[:len(testBase58Liquid)-1] + "J", NetworkLiquid, ErrInvalidChecksum},
+		{"liquidOnTestnet", testAddressLiquid, NetworkLiquidTestnet, ErrWrongAddressNetwork},
+		{"testnetOnLiquid", testAddressTestnet, NetworkLiquid, ErrWrongAddressNetwork},
+		{"base58OnRegtest", testBase58Liquid, NetworkElementsRegtest, ErrWrongAddressNetwork},
+		{"unconfidential", "ex1qsj2vaxlk8tcqqx2w6c7gsfqsaqlwpc4zrvegny", NetworkLiquid, ErrUnconfidentialAddress},
+		{"base58Unconfidential", "PwejqgUENiTaoim3YN55fUbUyJC9Mxmrht", NetworkLiquid, ErrUnconfidentialAddress},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.address.Validate(tt.network); err != tt.wantErr {
+				t.Errorf("ConfidentialAddress.Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := tt.address.Valid(tt.network); got != (tt.wantErr == nil) {
+				t.Errorf("ConfidentialAddress.Valid() = %v, want %v", got, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestNewConfidentialAddress(t *testing.T) {
+	t.Parallel()
+
+	key := sha256.Sum256([]byte("k"))
+	blindingKey := append([]byte{0x02}, key[:]...)
+	program := sha256.Sum256([]byte("p"))
+
+	got, err := NewConfidentialAddress(NetworkLiquidTestnet, blindingKey, 0, program[:20])
+	if err != nil {
+		t.Fatalf("NewConfidentialAddress() error = %v", err)
+	}
+	if got != testAddressTestnet {
+		t.Errorf("NewConfidentialAddress() = %v, want %v", got, testAddressTestnet)
+	}
+
+	info, err := got.Decode()
+	if err != nil {
+		t.Fatalf("ConfidentialAddress.Decode() error = %v", err)
+	}
+	if info.Network != NetworkLiquidTestnet || info.WitnessVersion != 0 || !bytes.Equal(info.BlindingKey, blindingKey) || !bytes.Equal(info.Program, program[:20]) {
+		t.Errorf("ConfidentialAddress.Decode() = %+v", info)
+	}
+
+	if _, err := NewConfidentialAddress(NetworkLiquid, key[:], 0, program[:]); err != ErrInvalidAddress {
+		t.Errorf("NewConfidentialAddress() invalid blinding key error = %v, want %v", err, ErrInvalidAddress)
+	}
+}
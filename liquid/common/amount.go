@@ -0,0 +1,142 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	SatoshiPerCoin = 100000000
+	MaxAmount      = Amount(21000000 * SatoshiPerCoin)
+)
+
+var (
+	ErrInvalidAmount = errors.New("Invalid Amount")
+)
+
+// Amount is a count of the asset smallest on-chain unit, satoshi for the policy asset
+// The asset display value is the amount divided by 10^precision,
+// json and elementsd amounts always use AmountPrecision
+type Amount int64
+
+// AmountFromFloat convert coin value to Amount, rounded to nearest satoshi
+func AmountFromFloat(value float64) Amount {
+	return Amount(math.Round(value * SatoshiPerCoin))
+}
+
+// ParseAmount parse exact decimal string with up to AmountPrecision decimals
+func ParseAmount(value string) (Amount, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	toks := strings.SplitN(value, ".", 2)
+	integer, decimals := toks[0], ""
+	if len(toks) == 2 {
+		decimals = toks[1]
+	}
+	if len(integer) == 0 && len(decimals) == 0 {
+		return 0, ErrInvalidAmount
+	}
+	if len(decimals) > AmountPrecision {
+		// extra decimals must be zero
+		if strings.Trim(decimals[AmountPrecision:], "0") != "" {
+			return 0, ErrInvalidAmount
+		}
+		decimals = decimals[:AmountPrecision]
+	}
+	decimals += strings.Repeat("0", AmountPrecision-len(decimals))
+
+	digits := strings.TrimLeft(integer+decimals, "0")
+	if len(digits) == 0 {
+		return 0, nil
+	}
+	if strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return 0, ErrInvalidAmount
+	}
+
+	result, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || Amount(result) > MaxAmount {
+		return 0, ErrInvalidAmount
+	}
+	if negative {
+		result = -result
+	}
+	return Amount(result), nil
+}
+
+// Float return the coin value, for display only
+func (p Amount) Float() float64 {
+	return float64(p) / SatoshiPerCoin
+}
+
+// String return exact coin value with AmountPrecision decimals
+func (p Amount) String() string {
+	return p.Format(AmountPrecision)
+}
+
+// Format return the display value with precision decimals, amount divided by 10^precision
+// AmountPrecision is used for invalid precision
+func (p Amount) Format(precision int) string {
+	if precision < 0 || precision > AmountPrecision {
+		precision = AmountPrecision
+	}
+
+	value := int64(p)
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	unit := int64(math.Pow10(precision))
+	integer := strconv.FormatInt(value/unit, 10)
+	if precision == 0 {
+		return sign + integer
+	}
+
+	decimals := strconv.FormatInt(value%unit, 10)
+	decimals = strings.Repeat("0", precision-len(decimals)) + decimals
+
+	return sign + integer + "." + decimals
+}
+
+// MarshalJSON encode amount as an exact json number
+func (p Amount) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalJSON decode exact json number or string
+func (p *Amount) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "null" {
+		return nil
+	}
+
+	// accept exponent notation from float encoders
+	if strings.ContainsAny(value, "eE") {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return ErrInvalidAmount
+		}
+		*p = AmountFromFloat(f)
+		return nil
+	}
+
+	amount, err := ParseAmount(value)
+	if err != nil {
+		return err
+	}
+	*p = amount
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    Amount
+		wantErr bool
+	}{
+		{"zero", "0", 0, false},
+		{"integer", "42", 4200000000, false},
+		{"decimals", "0.12345678", 12345678, false},
+		{"shortDecimals", "1.5", 150000000, false},
+		{"leadingDot", ".00000001", 1, false},
+		{"trailingZeros", "0.1000000000", 10000000, false},
+		{"negative", "-3.14159265", -314159265, false},
+		{"max", "21000000", MaxAmount, false},
+
+		{"empty", "", 0, true},
+		{"dot", ".", 0, true},
+		{"tooPrecise", "0.123456789", 0, true},
+		{"notNumber", "1.2a", 0, true},
+		{"twoDots", "1.2.3", 0, true},
+		{"overflow", "21000000.00000001", 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAmount() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseAmount() = %v, want %v", int64(got), int64(tt.want))
+			}
+		})
+	}
+}
+
+func TestAmount_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		amount    Amount
+		precision int
+		want      string
+	}{
+		{"default", 0, 8, "0.00000000"},
+		{"satoshi", 1, 8, "0.00000001"},
+		{"negative", -12345678, 8, "-0.12345678"},
+		{"precision2", 150, 2, "1.50"},
+		{"precision2Large", 123456, 2, "1234.56"},
+		{"precision2Negative", -150, 2, "-1.50"},
+		{"precision0", 42, 0, "42"},
+
+		{"invalidPrecision", 1, 12, "0.00000001"},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.Format(tt.precision); got != tt.want {
+				t.Errorf("Amount.Format() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmount_JSON(t *testing.T) {
+	t.Parallel()
+
+	var document struct {
+		Amount Amount `json:"amount"`
+	}
+
+	for _, data := range []string{`{"amount": 0.00001400}`, `{"amount": "0.000014"}`, `{"amount": 1.4e-05}`} {
+		if err := json.Unmarshal([]byte(data), &document); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if document.Amount != 1400 {
+			t.Errorf("json.Unmarshal(%s) = %v, want %v", data, int64(document.Amount), 1400)
+		}
+	}
+
+	data, _ := json.Marshal(&document)
+	if string(data) != `{"amount":0.00001400}` {
+		t.Errorf("json.Marshal() = %s", data)
+	}
+}
+
+func TestSwapProposal_DecodeLegacy(t *testing.T) {
+	t.Parallel()
+
+	legacy := legacySwapProposal{
+		Timestamp: time.Unix(1600000000, 0).UTC(),
+		SwapID:    42,
+		Address:   "address",
+		Proposal: legacyProposalInfo{
+			ProposerAsset:  "assetP",
+			ProposerAmount: 0.00001,
+			ReceiverAsset:  "assetR",
+			ReceiverAmount: 0.000014,
+		},
+		FeeRate: 150 / 100000000.0,
+		Payload: "payload",
+	}
+
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(&legacy); err != nil {
+		t.Fatalf("gob.Encode() error = %v", err)
+	}
+
+	var got SwapProposal
+	if err := got.Decode(buffer.Bytes()); err != nil {
+		t.Fatalf("SwapProposal.Decode() error = %v", err)
+	}
+	if got.SwapID != 42 || got.Payload != "payload" || !got.Timestamp.Equal(legacy.Timestamp) {
+		t.Errorf("SwapProposal.Decode() = %+v", got)
+	}
+	if got.Proposal.ProposerAmount != 1000 || got.Proposal.ReceiverAmount != 1400 || got.FeeRate != DefaultFeeRate {
+		t.Errorf("SwapProposal.Decode() wrong amounts %+v", got)
+	}
+
+	// current format
+	data, err := got.Encode()
+	if err != nil {
+		t.Fatalf("SwapProposal.Encode() error = %v", err)
+	}
+	var current SwapProposal
+	if err := current.Decode(data); err != nil || current.Proposal != got.Proposal {
+		t.Errorf("SwapProposal.Decode() = %+v, error = %v", current, err)
+	}
+}
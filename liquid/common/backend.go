@@ -0,0 +1,42 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrBackendNotFound = errors.New("Swap Backend Not Found")
+	ErrNoOutput        = errors.New("No Output")
+)
+
+// SwapBackend is the swap engine behind the liquid handlers.
+// Each method returns the resulting liquidswap payload.
+type SwapBackend interface {
+	Propose(ctx context.Context, address ConfidentialAddress, proposal ProposalInfo, feeRate Amount) (Payload, error)
+	Info(ctx context.Context, payload Payload) (Payload, error)
+	Accept(ctx context.Context, address ConfidentialAddress, payload Payload, feeRate Amount) (Payload, error)
+	// Finalize sign the accepted transaction, and send it if broadcast is set
+	// returns ErrTermsMismatch if the transaction does not match terms
+	Finalize(ctx context.Context, payload Payload, terms SwapTerms, broadcast bool) (Payload, error)
+	// Broadcast send a signed transaction and return its txid
+	Broadcast(ctx context.Context, tx string) (string, error)
+	// TxID return the transaction id of a signed transaction
+	TxID(ctx context.Context, tx string) (string, error)
+	// Cancel double spend the proposal inputs back to the wallet
+	Cancel(ctx context.Context, payload Payload, feeRate Amount) (Payload, error)
+
+	// LockUnspents exclude outpoints from coin selection until unlocked
+	LockUnspents(ctx context.Context, outpoints []Outpoint) error
+	UnlockUnspents(ctx context.Context, outpoints []Outpoint) error
+	// Balances return spendable wallet amounts by asset, locked unspents are excluded
+	Balances(ctx context.Context) (map[AssetID]Amount, error)
+
+	// Confirmations return the transaction confirmations count, 0 if in mempool
+	// returns ErrTxDropped if the transaction is unknown, ErrTxConflicted if double spent
+	Confirmations(ctx context.Context, txID string) (int, error)
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"sort"
+	"time"
+
+	"github.com/condensat/bank-core"
+)
+
+// Outpoint identify a wallet unspent
+type Outpoint struct {
+	TxID string `json:"txid"`
+	Vout int    `json:"vout"`
+}
+
+// AssetBalance is a wallet balance for an asset
+// Reserved unspents are committed to outstanding proposals and are not spendable
+type AssetBalance struct {
+	Asset     AssetID
+	Spendable Amount
+	Reserved  Amount
+}
+
+// SwapBalances is the wallet balances request and reply
+type SwapBalances struct {
+	Version   int // schema version
+	Timestamp time.Time
+	Wallet    string // empty for service default wallet
+	Balances  []AssetBalance
+	Error     *SwapError
+}
+
+// NewAssetBalances merge spendable and reserved amounts, sorted by asset
+func NewAssetBalances(spendable, reserved map[AssetID]Amount) []AssetBalance {
+	balances := make(map[AssetID]*AssetBalance)
+	balance := func(asset AssetID) *AssetBalance {
+		if _, ok := balances[asset]; !ok {
+			balances[asset] = &AssetBalance{Asset: asset}
+		}
+		return balances[asset]
+	}
+	for asset, amount := range spendable {
+		balance(asset).Spendable += amount
+	}
+	for asset, amount := range reserved {
+		balance(asset).Reserved += amount
+	}
+
+	var result []AssetBalance
+	for _, balance := range balances {
+		result = append(result, *balance)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Asset < result[j].Asset
+	})
+	return result
+}
+
+func (p *SwapBalances) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *SwapBalances) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	ProtocolVersion = 1
+)
+
+type PayloadEncoding int
+type PayloadStage string
+
+const (
+	PayloadBase64 = PayloadEncoding(iota)
+	PayloadJson
+
+	PayloadStageUnknown   = PayloadStage("unknown")
+	PayloadStageProposal  = PayloadStage("proposal")
+	PayloadStageAccepted  = PayloadStage("accepted")
+	PayloadStageFinalized = PayloadStage("finalized")
+	PayloadStageCancelled = PayloadStage("cancelled")
+)
+
+var (
+	ErrInvalidDocument            = errors.New("Invalid Document")
+	ErrUnsupportedProtocolVersion = errors.New("Unsupported Protocol Version")
+)
+
+// InputBlinder contains data required by the counterparty to blind the transaction
+type InputBlinder struct {
+	TxID          string `json:"txid"`
+	Vout          int    `json:"vout"`
+	Asset         string `json:"asset"`
+	Amount        Amount `json:"amount"`
+	AssetBlinder  string `json:"assetblinder"`
+	AmountBlinder string `json:"amountblinder"`
+}
+
+// ProposalDocument is the proposer unblinded transaction, with the swap terms
+type ProposalDocument struct {
+	ProtocolVersion int            `json:"protocol_version"`
+	Tx              string         `json:"tx"`
+	AssetP          AssetID        `json:"asset_p"`
+	AmountP         Amount         `json:"amount_p"`
+	AssetR          AssetID        `json:"asset_r"`
+	AmountR         Amount         `json:"amount_r"`
+	Inputs          []InputBlinder `json:"inputs"`
+}
+
+// AcceptedDocument is the blinded transaction, signed by the acceptor
+type AcceptedDocument struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Tx              string `json:"tx"`
+	Fee             Amount `json:"fee,omitempty"`
+}
+
+// FinalizedDocument is the fully signed transaction
+type FinalizedDocument struct {
+	TxID string `json:"txid"`
+	Tx   string `json:"tx"`
+}
+
+// CancelledDocument is the transaction spending back the proposer inputs
+type CancelledDocument struct {
+	CancelTxID string `json:"cancel_txid"`
+	Tx         string `json:"tx"`
+}
+
+// EncodePayload return the json document as a base64 or raw json Payload
+func EncodePayload(document interface{}, encoding PayloadEncoding) (Payload, error) {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+	if encoding == PayloadJson {
+		return Payload(data), nil
+	}
+	return Payload(base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// Encoding return PayloadBase64 if the payload is base64 encoded
+func (payload Payload) Encoding() PayloadEncoding {
+	if _, err := base64.StdEncoding.DecodeString(string(payload)); err != nil {
+		return PayloadJson
+	}
+	return PayloadBase64
+}
+
+// Decode unmarshal the base64 or raw json payload into document
+func (payload Payload) Decode(document interface{}) error {
+	return payload.decode(document, false)
+}
+
+func (payload Payload) decode(document interface{}, strict bool) error {
+	data := []byte(payload)
+	if payload.Encoding() == PayloadBase64 {
+		data, _ = base64.StdEncoding.DecodeString(string(payload))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(document); err != nil {
+		return ErrInvalidDocument
+	}
+	return nil
+}
+
+func (payload Payload) Proposal() (ProposalDocument, error) {
+	var result ProposalDocument
+	if err := payload.decode(&result, false); err != nil {
+		return ProposalDocument{}, err
+	}
+	if result.ProtocolVersion != ProtocolVersion {
+		return ProposalDocument{}, ErrUnsupportedProtocolVersion
+	}
+	if len(result.Tx) == 0 || len(result.Inputs) == 0 ||
+		len(result.AssetP) != AssetIDLength || len(result.AssetR) != AssetIDLength ||
+		result.AmountP <= 0.0 || result.AmountR <= 0.0 {
+		return ProposalDocument{}, ErrInvalidDocument
+	}
+	return result, nil
+}
+
+func (payload Payload) Accepted() (AcceptedDocument, error) {
+	var result AcceptedDocument
+	if err := payload.decode(&result, true); err != nil {
+		return AcceptedDocument{}, err
+	}
+	if result.ProtocolVersion != ProtocolVersion {
+		return AcceptedDocument{}, ErrUnsupportedProtocolVersion
+	}
+	if len(result.Tx) == 0 {
+		return AcceptedDocument{}, ErrInvalidDocument
+	}
+	return result, nil
+}
+
+func (payload Payload) Finalized() (FinalizedDocument, error) {
+	var result FinalizedDocument
+	if err := payload.decode(&result, true); err != nil {
+		return FinalizedDocument{}, err
+	}
+	if len(result.TxID) == 0 {
+		return FinalizedDocument{}, ErrInvalidDocument
+	}
+	return result, nil
+}
+
+func (payload Payload) Cancelled() (CancelledDocument, error) {
+	var result CancelledDocument
+	if err := payload.decode(&result, true); err != nil {
+		return CancelledDocument{}, err
+	}
+	if len(result.CancelTxID) == 0 {
+		return CancelledDocument{}, ErrInvalidDocument
+	}
+	return result, nil
+}
+
+// Stage return the swap stage of the payload document
+func (payload Payload) Stage() PayloadStage {
+	if _, err := payload.Proposal(); err == nil {
+		return PayloadStageProposal
+	}
+	if _, err := payload.Accepted(); err == nil {
+		return PayloadStageAccepted
+	}
+	if _, err := payload.Finalized(); err == nil {
+		return PayloadStageFinalized
+	}
+	if _, err := payload.Cancelled(); err == nil {
+		return PayloadStageCancelled
+	}
+	return PayloadStageUnknown
+}
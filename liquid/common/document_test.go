@@ -0,0 +1,99 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	testAssetP = AssetID("ce091c998b83c78bb71a632313ba3760f1763d9cfcffae02258ffa9865a37bd2")
+	testAssetR = AssetID("0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a")
+)
+
+func testProposalDocument() ProposalDocument {
+	return ProposalDocument{
+		ProtocolVersion: ProtocolVersion,
+		Tx:              "0200000000",
+		AssetP:          testAssetP,
+		AmountP:         1000,
+		AssetR:          testAssetR,
+		AmountR:         1400,
+		Inputs: []InputBlinder{
+			{TxID: "txid", Vout: 1, Asset: string(testAssetP), Amount: 2000},
+		},
+	}
+}
+
+func TestPayload_Proposal(t *testing.T) {
+	t.Parallel()
+
+	ref := testProposalDocument()
+	for _, encoding := range []PayloadEncoding{PayloadBase64, PayloadJson} {
+		payload, err := EncodePayload(&ref, encoding)
+		if err != nil {
+			t.Fatalf("EncodePayload() error = %v", err)
+		}
+		if payload.Encoding() != encoding {
+			t.Errorf("Payload.Encoding() = %v, want %v", payload.Encoding(), encoding)
+		}
+		if !payload.Valid() {
+			t.Errorf("Payload.Valid() = false, want true")
+		}
+
+		got, err := payload.Proposal()
+		if err != nil {
+			t.Fatalf("Payload.Proposal() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, ref) {
+			t.Errorf("Payload.Proposal() = %+v, want %+v", got, ref)
+		}
+
+		again, _ := EncodePayload(&got, encoding)
+		if again != payload {
+			t.Errorf("EncodePayload() round trip = %v, want %v", again, payload)
+		}
+	}
+}
+
+func TestPayload_Stage(t *testing.T) {
+	t.Parallel()
+
+	proposal := testProposalDocument()
+	noInputs := testProposalDocument()
+	noInputs.Inputs = nil
+	badVersion := testProposalDocument()
+	badVersion.ProtocolVersion = 42
+
+	encode := func(document interface{}) Payload {
+		payload, _ := EncodePayload(document, PayloadBase64)
+		return payload
+	}
+
+	tests := []struct {
+		name    string
+		payload Payload
+		want    PayloadStage
+	}{
+		{"default", "", PayloadStageUnknown},
+		{"invalid", "invalid json", PayloadStageUnknown},
+		{"noInputs", encode(&noInputs), PayloadStageUnknown},
+		{"badVersion", encode(&badVersion), PayloadStageUnknown},
+
+		{"proposal", encode(&proposal), PayloadStageProposal},
+		{"accepted", encode(&AcceptedDocument{ProtocolVersion: ProtocolVersion, Tx: "02", Fee: 500}), PayloadStageAccepted},
+		{"finalized", `{"txid": "txid", "tx": "02"}`, PayloadStageFinalized},
+		{"cancelled", `{"cancel_txid": "txid", "tx": "02"}`, PayloadStageCancelled},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.Stage(); got != tt.want {
+				t.Errorf("Payload.Stage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
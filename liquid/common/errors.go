@@ -0,0 +1,139 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+)
+
+type ErrorCode string
+
+const (
+	ErrorCodeInternal           = ErrorCode("Internal")
+	ErrorCodeInvalidAddress     = ErrorCode("InvalidAddress")
+	ErrorCodeInvalidProposal    = ErrorCode("InvalidProposal")
+	ErrorCodeInvalidPayload     = ErrorCode("InvalidPayload")
+	ErrorCodeInvalidState       = ErrorCode("InvalidState")
+	ErrorCodeInsufficientFunds  = ErrorCode("InsufficientFunds")
+	ErrorCodeProposalExpired    = ErrorCode("ProposalExpired")
+	ErrorCodeWalletNotFound     = ErrorCode("WalletNotFound")
+	ErrorCodeWalletBusy         = ErrorCode("WalletBusy")
+	ErrorCodeBackendUnavailable = ErrorCode("BackendUnavailable")
+	ErrorCodeWalletLocked       = ErrorCode("WalletLocked")
+	ErrorCodeAssetMismatch      = ErrorCode("AssetMismatch")
+	ErrorCodeFeeTooLow          = ErrorCode("FeeTooLow")
+	ErrorCodeTxDropped          = ErrorCode("TxDropped")
+	ErrorCodeTxConflicted       = ErrorCode("TxConflicted")
+	ErrorCodeBroadcastExpired   = ErrorCode("BroadcastExpired")
+	ErrorCodeUnsupportedVersion = ErrorCode("UnsupportedVersion")
+	ErrorCodeTimeout            = ErrorCode("Timeout")
+	ErrorCodeBusy               = ErrorCode("Busy")
+	ErrorCodeTermsMismatch      = ErrorCode("TermsMismatch")
+	ErrorCodeRequestConflict    = ErrorCode("RequestConflict")
+)
+
+var (
+	ErrInternal           = errors.New("Internal Error")
+	ErrInvalidSwapState   = errors.New("Invalid Swap State Transition")
+	ErrInsufficientFunds  = errors.New("Insufficient Funds")
+	ErrProposalExpired    = errors.New("Proposal Expired")
+	ErrWalletBusy         = errors.New("Wallet Busy")
+	ErrBackendUnavailable = errors.New("Swap Backend Unavailable")
+	ErrWalletLocked       = errors.New("Wallet Locked")
+	ErrAssetMismatch      = errors.New("Asset Mismatch")
+	ErrFeeTooLow          = errors.New("Fee Too Low")
+	ErrTxDropped          = errors.New("Transaction Dropped")
+	ErrTxConflicted       = errors.New("Transaction Conflicted")
+	ErrBroadcastExpired   = errors.New("Broadcast Window Expired")
+	ErrBusy               = errors.New("Swap Service Busy")
+	ErrRequestConflict    = errors.New("Request Conflict")
+)
+
+// SwapError is the error returned in swap responses
+type SwapError struct {
+	Code      ErrorCode
+	Message   string
+	Retryable bool
+}
+
+type errorInfo struct {
+	Code      ErrorCode
+	Err       error
+	Retryable bool
+}
+
+// errorCodes list errors exposed to clients, first match is used
+var errorCodes = []errorInfo{
+	{ErrorCodeInvalidAddress, ErrInvalidAddress, false},
+	{ErrorCodeInvalidProposal, ErrInvalidProposal, false},
+	{ErrorCodeInvalidPayload, ErrInvalidPayload, false},
+	{ErrorCodeInvalidPayload, ErrInvalidDocument, false},
+	{ErrorCodeInvalidPayload, ErrUnsupportedProtocolVersion, false},
+	{ErrorCodeInvalidState, ErrInvalidSwapState, false},
+	{ErrorCodeInsufficientFunds, ErrInsufficientFunds, false},
+	{ErrorCodeProposalExpired, ErrProposalExpired, false},
+	{ErrorCodeWalletNotFound, ErrWalletNotFound, false},
+	{ErrorCodeWalletBusy, ErrWalletBusy, true},
+	{ErrorCodeBackendUnavailable, ErrBackendUnavailable, true},
+	{ErrorCodeBackendUnavailable, ErrBackendNotFound, true},
+	{ErrorCodeWalletLocked, ErrWalletLocked, false},
+	{ErrorCodeAssetMismatch, ErrAssetMismatch, false},
+	{ErrorCodeFeeTooLow, ErrFeeTooLow, false},
+	{ErrorCodeTxDropped, ErrTxDropped, false},
+	{ErrorCodeTxConflicted, ErrTxConflicted, false},
+	{ErrorCodeBroadcastExpired, ErrBroadcastExpired, false},
+	{ErrorCodeUnsupportedVersion, ErrUnsupportedSchemaVersion, false},
+	{ErrorCodeTimeout, ErrRequestTimeout, true},
+	{ErrorCodeBusy, ErrBusy, true},
+	{ErrorCodeTermsMismatch, ErrTermsMismatch, false},
+	{ErrorCodeRequestConflict, ErrRequestConflict, false},
+	{ErrorCodeInternal, ErrInternal, false},
+}
+
+// NewSwapError return the SwapError for err, unknown errors are returned as ErrorCodeInternal
+func NewSwapError(err error) *SwapError {
+	if err == nil {
+		return nil
+	}
+
+	var swapError *SwapError
+	if errors.As(err, &swapError) {
+		return swapError
+	}
+
+	for _, info := range errorCodes {
+		if errors.Is(err, info.Err) {
+			return &SwapError{
+				Code:      info.Code,
+				Message:   info.Err.Error(),
+				Retryable: info.Retryable,
+			}
+		}
+	}
+
+	return &SwapError{
+		Code:    ErrorCodeInternal,
+		Message: ErrInternal.Error(),
+	}
+}
+
+func (p *SwapError) Error() string {
+	return p.Message
+}
+
+// Unwrap return the sentinel error for Code, for use with errors.Is
+func (p *SwapError) Unwrap() error {
+	return p.Code.Err()
+}
+
+// Err return the sentinel error for code
+func (p ErrorCode) Err() error {
+	for _, info := range errorCodes {
+		if info.Code == p {
+			return info.Err
+		}
+	}
+	return ErrInternal
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewSwapError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      ErrorCode
+		wantRetryable bool
+	}{
+		{"insufficientFunds", ErrInsufficientFunds, ErrorCodeInsufficientFunds, false},
+		{"wrapped", fmt.Errorf("propose: %w", ErrInsufficientFunds), ErrorCodeInsufficientFunds, false},
+		{"document", ErrInvalidDocument, ErrorCodeInvalidPayload, false},
+		{"backendUnavailable", ErrBackendUnavailable, ErrorCodeBackendUnavailable, true},
+		{"walletBusy", ErrWalletBusy, ErrorCodeWalletBusy, true},
+		{"timeout", ErrRequestTimeout, ErrorCodeTimeout, true},
+		{"busy", ErrBusy, ErrorCodeBusy, true},
+		{"broadcastExpired", ErrBroadcastExpired, ErrorCodeBroadcastExpired, false},
+		{"unknown", errors.New("secret details"), ErrorCodeInternal, false},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewSwapError(tt.err)
+			if got.Code != tt.wantCode || got.Retryable != tt.wantRetryable {
+				t.Errorf("NewSwapError() = %+v, want %v retryable %v", got, tt.wantCode, tt.wantRetryable)
+			}
+			if !errors.Is(got, tt.wantCode.Err()) {
+				t.Errorf("NewSwapError() must match %v", tt.wantCode.Err())
+			}
+			// internal details are not exposed
+			if tt.wantCode == ErrorCodeInternal && got.Message != ErrInternal.Error() {
+				t.Errorf("NewSwapError() Message = %v, want %v", got.Message, ErrInternal)
+			}
+		})
+	}
+
+	if NewSwapError(nil) != nil {
+		t.Errorf("NewSwapError(nil) must be nil")
+	}
+}
+
+func TestSwapError_Encode(t *testing.T) {
+	t.Parallel()
+
+	reply := SwapProposal{SwapID: 42, Error: NewSwapError(ErrProposalExpired)}
+	data, err := reply.Encode()
+	if err != nil {
+		t.Fatalf("SwapProposal.Encode() error = %v", err)
+	}
+
+	var got SwapProposal
+	if err := got.Decode(data); err != nil {
+		t.Fatalf("SwapProposal.Decode() error = %v", err)
+	}
+	if got.Error == nil || !errors.Is(got.Error, ErrProposalExpired) {
+		t.Errorf("SwapProposal.Decode() Error = %v, want %v", got.Error, ErrProposalExpired)
+	}
+	if errors.Is(got.Error, ErrInsufficientFunds) {
+		t.Errorf("SwapError must not match other errors")
+	}
+}
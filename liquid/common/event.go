@@ -0,0 +1,45 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"time"
+
+	"github.com/condensat/bank-core"
+)
+
+// SwapEvent is published when a swap state change
+// Error is set for Failed swaps
+type SwapEvent struct {
+	Version       int // schema version, zero for SchemaVersion1
+	Timestamp     time.Time
+	SwapID        uint64
+	Wallet        string
+	State         string
+	Proposal      ProposalInfo
+	TxID          string
+	Confirmations int
+	Error         *SwapError
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// SwapEventStateSubject return the event subject for state, use "*" for all states
+func SwapEventStateSubject(state string) string {
+	return SwapEventSubject + "." + state
+}
+
+func (p *SwapEvent) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *SwapEvent) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
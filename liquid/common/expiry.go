@@ -0,0 +1,108 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultProposalTTL = 15 * time.Minute
+)
+
+var (
+	ErrInvalidProposalTTL = errors.New("Invalid Proposal TTL")
+)
+
+type assetPair [2]AssetID
+
+// newAssetPair is order independent
+func newAssetPair(assetP, assetR AssetID) assetPair {
+	if assetP > assetR {
+		assetP, assetR = assetR, assetP
+	}
+	return assetPair{assetP, assetR}
+}
+
+// ProposalTTL is the proposal time to live per asset pair
+type ProposalTTL struct {
+	defaultTTL time.Duration
+	pairs      map[assetPair]time.Duration
+}
+
+func NewProposalTTL(defaultTTL time.Duration) *ProposalTTL {
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultProposalTTL
+	}
+	return &ProposalTTL{
+		defaultTTL: defaultTTL,
+		pairs:      make(map[assetPair]time.Duration),
+	}
+}
+
+// ParseProposalTTL parse comma separated pair ttl, ex: "USDt/LCAD=5m,L-BTC/USDt=2m"
+// assets are resolved with registry
+func ParseProposalTTL(defaultTTL time.Duration, pairs string, registry *AssetRegistry) (*ProposalTTL, error) {
+	result := NewProposalTTL(defaultTTL)
+
+	for _, entry := range strings.Split(pairs, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		toks := strings.Split(entry, "=")
+		if len(toks) != 2 {
+			return nil, ErrInvalidProposalTTL
+		}
+		assets := strings.Split(toks[0], "/")
+		if len(assets) != 2 {
+			return nil, ErrInvalidProposalTTL
+		}
+		ttl, err := time.ParseDuration(toks[1])
+		if err != nil || ttl <= 0 {
+			return nil, ErrInvalidProposalTTL
+		}
+
+		assetP, err := registry.Resolve(AssetID(strings.TrimSpace(assets[0])))
+		if err != nil {
+			return nil, err
+		}
+		assetR, err := registry.Resolve(AssetID(strings.TrimSpace(assets[1])))
+		if err != nil {
+			return nil, err
+		}
+		result.SetPair(assetP, assetR, ttl)
+	}
+
+	return result, nil
+}
+
+func (p *ProposalTTL) SetPair(assetP, assetR AssetID, ttl time.Duration) {
+	p.pairs[newAssetPair(assetP, assetR)] = ttl
+}
+
+// TTL return the asset pair ttl or the default ttl
+func (p *ProposalTTL) TTL(proposal ProposalInfo) time.Duration {
+	if ttl, ok := p.pairs[newAssetPair(proposal.ProposerAsset, proposal.ReceiverAsset)]; ok {
+		return ttl
+	}
+	return p.defaultTTL
+}
+
+// Expired return true if expiry is set and reached
+func Expired(expiry time.Time) bool {
+	return !expiry.IsZero() && !time.Now().Before(expiry)
+}
+
+// EarliestExpiry return the earliest set expiry, zero if none is set
+func EarliestExpiry(expiry, other time.Time) time.Time {
+	if expiry.IsZero() || (!other.IsZero() && other.Before(expiry)) {
+		return other
+	}
+	return expiry
+}
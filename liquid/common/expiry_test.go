@@ -0,0 +1,100 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseProposalTTL(t *testing.T) {
+	t.Parallel()
+
+	registry := NewAssetRegistry(
+		AssetInfo{AssetID: testAssetP, Ticker: "USDt", Precision: 8},
+		AssetInfo{AssetID: testAssetR, Ticker: "LCAD", Precision: 8},
+	)
+
+	tests := []struct {
+		name     string
+		pairs    string
+		proposal ProposalInfo
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"empty", "", ProposalInfo{ProposerAsset: testAssetP, ReceiverAsset: testAssetR}, time.Hour, false},
+		{"ticker", "USDt/LCAD=5m", ProposalInfo{ProposerAsset: testAssetP, ReceiverAsset: testAssetR}, 5 * time.Minute, false},
+		{"reverse", "usdt/lcad=5m", ProposalInfo{ProposerAsset: testAssetR, ReceiverAsset: testAssetP}, 5 * time.Minute, false},
+		{"assetID", string(testAssetR) + "/" + string(testAssetP) + "=30s", ProposalInfo{ProposerAsset: testAssetP, ReceiverAsset: testAssetR}, 30 * time.Second, false},
+		{"otherPair", "USDt/LCAD=5m", ProposalInfo{ProposerAsset: testAssetP, ReceiverAsset: testAssetCents}, time.Hour, false},
+
+		{"unknownAsset", "USDt/EUR=5m", ProposalInfo{}, 0, true},
+		{"invalidDuration", "USDt/LCAD=5", ProposalInfo{}, 0, true},
+		{"negativeDuration", "USDt/LCAD=-5m", ProposalInfo{}, 0, true},
+		{"invalidPair", "USDt=5m", ProposalInfo{}, 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseProposalTTL(time.Hour, tt.pairs, registry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseProposalTTL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if ttl := got.TTL(tt.proposal); ttl != tt.want {
+				t.Errorf("ProposalTTL.TTL() = %v, want %v", ttl, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	t.Parallel()
+
+	if Expired(time.Time{}) {
+		t.Errorf("Expired() zero expiry must not expire")
+	}
+	if !Expired(time.Now().Add(-time.Second)) {
+		t.Errorf("Expired() past expiry must expire")
+	}
+	if Expired(time.Now().Add(time.Minute)) {
+		t.Errorf("Expired() future expiry must not expire")
+	}
+}
+
+func TestEarliestExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	later := now.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		expiry time.Time
+		other  time.Time
+		want   time.Time
+	}{
+		{"none", time.Time{}, time.Time{}, time.Time{}},
+		{"expiry", now, time.Time{}, now},
+		{"other", time.Time{}, now, now},
+		{"earlierExpiry", now, later, now},
+		{"earlierOther", later, now, now},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := EarliestExpiry(tt.expiry, tt.other); !got.Equal(tt.want) {
+				t.Errorf("EarliestExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+// SwapInfo is the structured result of a swap info request
+// Locked is set once the acceptor has blinded and signed the transaction,
+// swap terms can not change anymore
+type SwapInfo struct {
+	Stage           PayloadStage `json:"stage"`
+	ProtocolVersion int          `json:"protocol_version"`
+	TxID            string       `json:"txid,omitempty"`
+
+	ProposerAsset  AssetID `json:"asset_p,omitempty"`
+	ProposerAmount Amount  `json:"amount_p,omitempty"`
+	ReceiverAsset  AssetID `json:"asset_r,omitempty"`
+	ReceiverAmount Amount  `json:"amount_r,omitempty"`
+	Fee            Amount  `json:"fee,omitempty"`
+
+	Locked  bool `json:"locked"`
+	Inputs  int  `json:"inputs"`
+	Outputs int  `json:"outputs"`
+}
+
+// IsProposer return true if the swap is waiting for the acceptor
+func (p *SwapInfo) IsProposer() bool {
+	return p.Stage == PayloadStageProposal
+}
+
+// IsAcceptor return true if the swap was accepted and waiting for finalization
+func (p *SwapInfo) IsAcceptor() bool {
+	return p.Stage == PayloadStageAccepted
+}
+
+// NewSwapInfo create SwapInfo from the request payload document
+// and the raw info output from the swap backend
+func NewSwapInfo(payload, info Payload) SwapInfo {
+	result := SwapInfo{
+		Stage: payload.Stage(),
+	}
+
+	switch result.Stage {
+	case PayloadStageProposal:
+		proposal, _ := payload.Proposal()
+		result.ProtocolVersion = proposal.ProtocolVersion
+		result.ProposerAsset = proposal.AssetP
+		result.ProposerAmount = proposal.AmountP
+		result.ReceiverAsset = proposal.AssetR
+		result.ReceiverAmount = proposal.AmountR
+		result.Inputs = len(proposal.Inputs)
+
+	case PayloadStageAccepted:
+		accepted, _ := payload.Accepted()
+		result.ProtocolVersion = accepted.ProtocolVersion
+		result.Fee = accepted.Fee
+		result.Locked = true
+	}
+
+	// backend info override document values
+	_ = info.Decode(&result)
+
+	return result
+}
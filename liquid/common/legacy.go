@@ -0,0 +1,49 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// legacy wire format, with float64 amounts
+
+type legacyProposalInfo struct {
+	ProposerAsset  AssetID
+	ProposerAmount float64
+	ReceiverAsset  AssetID
+	ReceiverAmount float64
+}
+
+type legacySwapProposal struct {
+	Timestamp time.Time
+	SwapID    uint64
+	Address   ConfidentialAddress
+	Proposal  legacyProposalInfo
+	FeeRate   float64 // BTC/Kb
+	Payload   Payload
+}
+
+func (p *legacySwapProposal) Decode(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(p)
+}
+
+func (p *legacySwapProposal) SwapProposal() SwapProposal {
+	return SwapProposal{
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Address:   p.Address,
+		Proposal: ProposalInfo{
+			ProposerAsset:  p.Proposal.ProposerAsset,
+			ProposerAmount: AmountFromFloat(p.Proposal.ProposerAmount),
+			ReceiverAsset:  p.Proposal.ReceiverAsset,
+			ReceiverAmount: AmountFromFloat(p.Proposal.ReceiverAmount),
+		},
+		FeeRate: AmountFromFloat(p.FeeRate),
+		Payload: p.Payload,
+	}
+}
@@ -11,4 +11,11 @@ const (
 	SwapInfoProposalSubject     = chanPrefix + "Swap.InfoProposal"
 	SwapFinalizeProposalSubject = chanPrefix + "Swap.FinalizeProposal"
 	SwapAcceptProposalSubject   = chanPrefix + "Swap.AcceptProposal"
+	SwapCancelProposalSubject   = chanPrefix + "Swap.CancelProposal"
+	SwapBalancesSubject         = chanPrefix + "Swap.Balances"
+	SwapBroadcastSubject        = chanPrefix + "Swap.Broadcast"
+	SwapQueueStatusSubject      = chanPrefix + "Swap.QueueStatus"
+
+	// SwapEventSubject is the prefix for swap events, followed by the swap state
+	SwapEventSubject = chanPrefix + "Swap.Event"
 )
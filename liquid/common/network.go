@@ -0,0 +1,131 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type Network string
+
+const (
+	NetworkLiquid          = Network("liquidv1")
+	NetworkLiquidTestnet   = Network("liquidtestnet")
+	NetworkElementsRegtest = Network("elementsregtest")
+
+	DefaultNetwork = NetworkLiquid
+
+	SwapNetworkKey = "Key.SwapNetworkKey"
+)
+
+var (
+	ErrInvalidNetwork = errors.New("Invalid Network")
+)
+
+// NetworkParams are the chain parameters used by the swap service
+type NetworkParams struct {
+	Network        Network
+	Blech32HRP     string // confidential segwit addresses
+	Bech32HRP      string // unconfidential segwit addresses
+	PolicyAsset    AssetInfo
+	DefaultFeeRate Amount // satoshi/Kb
+}
+
+var networks = map[Network]NetworkParams{
+	NetworkLiquid: {
+		Network:    NetworkLiquid,
+		Blech32HRP: "lq",
+		Bech32HRP:  "ex",
+		PolicyAsset: AssetInfo{
+			AssetID:   "6f0279e9ed041c3d710a9f57d0c02928416460c4b722ae3457a11eec381c526d",
+			Name:      "Liquid Bitcoin",
+			Ticker:    "L-BTC",
+			Precision: AmountPrecision,
+		},
+		DefaultFeeRate: DefaultFeeRate,
+	},
+	NetworkLiquidTestnet: {
+		Network:    NetworkLiquidTestnet,
+		Blech32HRP: "tlq",
+		Bech32HRP:  "tex",
+		PolicyAsset: AssetInfo{
+			AssetID:   "144c654344aa716d6f3abcc1ca90e5641e4e2a7f633bc09fe3baf64585819a49",
+			Name:      "Testnet Liquid Bitcoin",
+			Ticker:    "tL-BTC",
+			Precision: AmountPrecision,
+		},
+		DefaultFeeRate: DefaultFeeRate,
+	},
+	NetworkElementsRegtest: {
+		Network:    NetworkElementsRegtest,
+		Blech32HRP: "el",
+		Bech32HRP:  "ert",
+		PolicyAsset: AssetInfo{
+			AssetID:   "5ac9f65c0efcc4775e0baec4ec03abdde22473cd3cf33c0419ca290e0751b225",
+			Name:      "Regtest Bitcoin",
+			Ticker:    "rBTC",
+			Precision: AmountPrecision,
+		},
+		// elementsd default minimum relay fee
+		DefaultFeeRate: Amount(1000),
+	},
+}
+
+func ParseNetwork(network string) (Network, error) {
+	result := Network(strings.ToLower(network))
+	if _, ok := networks[result]; !ok {
+		return "", ErrInvalidNetwork
+	}
+	return result, nil
+}
+
+func (p Network) Valid() bool {
+	_, ok := networks[p]
+	return ok
+}
+
+// Params return DefaultNetwork parameters for unknown network
+func (p Network) Params() NetworkParams {
+	if params, ok := networks[p]; ok {
+		return params
+	}
+	return networks[DefaultNetwork]
+}
+
+// Namespace return key suffixed with network name.
+// DefaultNetwork keys are unchanged for compatibility.
+func (p Network) Namespace(key string) string {
+	if !p.Valid() || p == DefaultNetwork {
+		return key
+	}
+	return key + "." + string(p)
+}
+
+// Subject return the NATS subject for the network
+func (p Network) Subject(subject string) string {
+	if !strings.HasPrefix(subject, chanPrefix) {
+		return subject
+	}
+	return p.Namespace(strings.TrimSuffix(chanPrefix, ".")) + "." + strings.TrimPrefix(subject, chanPrefix)
+}
+
+func NetworkContext(ctx context.Context, network Network) context.Context {
+	return context.WithValue(ctx, SwapNetworkKey, network)
+}
+
+// NetworkFromContext return DefaultNetwork if no network is set
+func NetworkFromContext(ctx context.Context) Network {
+	if network, ok := ctx.Value(SwapNetworkKey).(Network); ok && network.Valid() {
+		return network
+	}
+	return DefaultNetwork
+}
+
+// NewNetworkAssetRegistry return a registry with the network policy asset
+func NewNetworkAssetRegistry(network Network) *AssetRegistry {
+	return NewAssetRegistry(network.Params().PolicyAsset)
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNetwork_Subject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		network Network
+		subject string
+		want    string
+	}{
+		{"liquid", NetworkLiquid, SwapCreateProposalSubject, "Condensat.Liquid.Swap.CreateProposal"},
+		{"unknown", Network("unknown"), SwapCreateProposalSubject, "Condensat.Liquid.Swap.CreateProposal"},
+		{"testnet", NetworkLiquidTestnet, SwapCreateProposalSubject, "Condensat.Liquid.liquidtestnet.Swap.CreateProposal"},
+		{"regtest", NetworkElementsRegtest, SwapAcceptProposalSubject, "Condensat.Liquid.elementsregtest.Swap.AcceptProposal"},
+		{"other", NetworkLiquidTestnet, "Other.Subject", "Other.Subject"},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.network.Subject(tt.subject); got != tt.want {
+				t.Errorf("Network.Subject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNetwork(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		network string
+		want    Network
+		wantErr bool
+	}{
+		{"liquid", "liquidv1", NetworkLiquid, false},
+		{"upper", "LiquidTestnet", NetworkLiquidTestnet, false},
+		{"regtest", "elementsregtest", NetworkElementsRegtest, false},
+		{"empty", "", "", true},
+		{"bitcoin", "main", "", true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseNetwork(tt.network)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseNetwork() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseNetwork() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetworkFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if got := NetworkFromContext(ctx); got != DefaultNetwork {
+		t.Errorf("NetworkFromContext() = %v, want %v", got, DefaultNetwork)
+	}
+	if got := NetworkFromContext(NetworkContext(ctx, NetworkLiquidTestnet)); got != NetworkLiquidTestnet {
+		t.Errorf("NetworkFromContext() = %v, want %v", got, NetworkLiquidTestnet)
+	}
+}
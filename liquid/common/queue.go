@@ -0,0 +1,56 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"sort"
+	"time"
+
+	"github.com/condensat/bank-core"
+)
+
+// QueueStatus is the work queue state for a wallet
+type QueueStatus struct {
+	Wallet   string
+	Depth    int // waiting requests
+	Running  int
+	Capacity int
+
+	Accepted uint64
+	Rejected uint64 // queue full or wait timeout
+
+	LastWait time.Duration
+	AvgWait  time.Duration
+	MaxWait  time.Duration
+}
+
+// SwapQueueStatus report all wallets work queues of a swap service instance
+type SwapQueueStatus struct {
+	Version   int
+	Timestamp time.Time
+	Hostname  string // replying instance
+	Queues    []QueueStatus
+	Error     *SwapError
+}
+
+// SortQueueStatus sort queues by wallet
+func SortQueueStatus(queues []QueueStatus) {
+	sort.Slice(queues, func(i, j int) bool {
+		return queues[i].Wallet < queues[j].Wallet
+	})
+}
+
+// BankObject interface
+
+func (p *SwapQueueStatus) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *SwapQueueStatus) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
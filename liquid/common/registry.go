@@ -0,0 +1,182 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidAssetRegistry = errors.New("Invalid Asset Registry")
+	ErrAssetNotFound        = errors.New("Asset Not Found")
+)
+
+// AssetInfo is the asset registry entry for an issued asset
+type AssetInfo struct {
+	AssetID   AssetID `json:"asset_id"`
+	Name      string  `json:"name"`
+	Ticker    string  `json:"ticker"`
+	Precision int     `json:"precision"`
+	Entity    struct {
+		Domain string `json:"domain"`
+	} `json:"entity"`
+}
+
+// AssetRegistry provide asset lookups by AssetID or ticker
+type AssetRegistry struct {
+	byID     map[AssetID]AssetInfo
+	byTicker map[string]AssetID
+}
+
+var (
+	registryLock    sync.RWMutex
+	defaultRegistry = NewAssetRegistry()
+)
+
+// SetAssetRegistry replace the registry used by ProposalInfo
+func SetAssetRegistry(registry *AssetRegistry) {
+	if registry == nil {
+		registry = NewAssetRegistry()
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	defaultRegistry = registry
+}
+
+func DefaultAssetRegistry() *AssetRegistry {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return defaultRegistry
+}
+
+func NewAssetRegistry(assets ...AssetInfo) *AssetRegistry {
+	result := AssetRegistry{
+		byID:     make(map[AssetID]AssetInfo),
+		byTicker: make(map[string]AssetID),
+	}
+	for _, asset := range assets {
+		result.add(asset)
+	}
+	return &result
+}
+
+// LoadAssetRegistry load a Blockstream asset-registry json file
+func LoadAssetRegistry(filename string) (*AssetRegistry, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAssetRegistry(data)
+}
+
+// ParseAssetRegistry accept the registry index (object keyed by asset id),
+// a list of assets or a single asset entry
+func ParseAssetRegistry(data []byte) (*AssetRegistry, error) {
+	var assets []AssetInfo
+
+	var single AssetInfo
+	var index map[AssetID]AssetInfo
+	switch {
+	case json.Unmarshal(data, &assets) == nil:
+
+	case json.Unmarshal(data, &single) == nil && len(single.AssetID) > 0:
+		assets = append(assets, single)
+
+	case json.Unmarshal(data, &index) == nil:
+		for assetID, asset := range index {
+			if len(asset.AssetID) == 0 {
+				asset.AssetID = assetID
+			}
+			assets = append(assets, asset)
+		}
+
+	default:
+		return nil, ErrInvalidAssetRegistry
+	}
+
+	for _, asset := range assets {
+		if !asset.AssetID.Valid() || asset.Precision < 0 || asset.Precision > AmountPrecision {
+			return nil, ErrInvalidAssetRegistry
+		}
+	}
+
+	return NewAssetRegistry(assets...), nil
+}
+
+func (p *AssetRegistry) add(asset AssetInfo) {
+	asset.AssetID = AssetID(strings.ToLower(string(asset.AssetID)))
+	p.byID[asset.AssetID] = asset
+	if len(asset.Ticker) > 0 {
+		p.byTicker[strings.ToUpper(asset.Ticker)] = asset.AssetID
+	}
+}
+
+// Merge add other registry assets, other entries take precedence
+func (p *AssetRegistry) Merge(other *AssetRegistry) *AssetRegistry {
+	if other == nil {
+		return p
+	}
+	for _, asset := range other.byID {
+		p.add(asset)
+	}
+	return p
+}
+
+func (p *AssetRegistry) ByID(assetID AssetID) (AssetInfo, bool) {
+	asset, ok := p.byID[AssetID(strings.ToLower(string(assetID)))]
+	return asset, ok
+}
+
+// ByTicker lookup is case insensitive
+func (p *AssetRegistry) ByTicker(ticker string) (AssetInfo, bool) {
+	assetID, ok := p.byTicker[strings.ToUpper(ticker)]
+	if !ok {
+		return AssetInfo{}, false
+	}
+	return p.ByID(assetID)
+}
+
+// Lookup search by AssetID, then by ticker
+func (p *AssetRegistry) Lookup(asset string) (AssetInfo, bool) {
+	if AssetID(asset).Valid() {
+		return p.ByID(AssetID(asset))
+	}
+	return p.ByTicker(asset)
+}
+
+// Precision return asset precision, AmountPrecision for unknown assets
+func (p *AssetRegistry) Precision(assetID AssetID) int {
+	if asset, ok := p.ByID(assetID); ok {
+		return asset.Precision
+	}
+	return AmountPrecision
+}
+
+// Resolve return AssetID from AssetID or ticker
+func (p *AssetRegistry) Resolve(asset AssetID) (AssetID, error) {
+	if asset.Valid() {
+		return asset, nil
+	}
+	info, ok := p.ByTicker(string(asset))
+	if !ok {
+		return "", ErrAssetNotFound
+	}
+	return info.AssetID, nil
+}
+
+// Valid return true for 64 hex chars AssetID
+func (p AssetID) Valid() bool {
+	if len(p) != AssetIDLength {
+		return false
+	}
+	_, err := hex.DecodeString(string(p))
+	return err == nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	testAssetCents = AssetID("1111111111111111111111111111111111111111111111111111111111111111")
+
+	testRegistryIndex = `{
+  "ce091c998b83c78bb71a632313ba3760f1763d9cfcffae02258ffa9865a37bd2": {
+    "asset_id": "ce091c998b83c78bb71a632313ba3760f1763d9cfcffae02258ffa9865a37bd2",
+    "contract": {"entity": {"domain": "tether.to"}, "name": "Tether USD", "precision": 8, "ticker": "USDt", "version": 0},
+    "entity": {"domain": "tether.to"},
+    "issuance_txin": {"txid": "abb4080d91849e933ee2ed65da6b436f7c385cf363fb4aa08399f1e27c58ff3d", "vin": 0},
+    "name": "Tether USD",
+    "precision": 8,
+    "ticker": "USDt",
+    "version": 0
+  },
+  "1111111111111111111111111111111111111111111111111111111111111111": {
+    "entity": {"domain": "example.com"},
+    "name": "Cents",
+    "precision": 2,
+    "ticker": "CTS"
+  }
+}`
+)
+
+func TestParseAssetRegistry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{"index", testRegistryIndex, 2, false},
+		{"single", `{"asset_id": "0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a", "ticker": "LCAD", "precision": 8}`, 1, false},
+		{"list", `[{"asset_id": "0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a", "ticker": "LCAD", "precision": 8}]`, 1, false},
+
+		{"invalidJson", `invalid`, 0, true},
+		{"invalidAssetID", `[{"asset_id": "LCAD", "precision": 8}]`, 0, true},
+		{"invalidPrecision", `[{"asset_id": "0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a", "precision": 9}]`, 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAssetRegistry([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAssetRegistry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil && len(got.byID) != tt.want {
+				t.Errorf("ParseAssetRegistry() = %v assets, want %v", len(got.byID), tt.want)
+			}
+		})
+	}
+}
+
+func TestAssetRegistry_Lookup(t *testing.T) {
+	t.Parallel()
+
+	registry, err := ParseAssetRegistry([]byte(testRegistryIndex))
+	if err != nil {
+		t.Fatalf("ParseAssetRegistry() error = %v", err)
+	}
+
+	usdt, ok := registry.ByID(testAssetP)
+	if !ok || usdt.Ticker != "USDt" || usdt.Name != "Tether USD" || usdt.Entity.Domain != "tether.to" {
+		t.Errorf("ByID() = %+v", usdt)
+	}
+	if got, ok := registry.ByTicker("usdt"); !ok || !reflect.DeepEqual(got, usdt) {
+		t.Errorf("ByTicker() = %+v", got)
+	}
+	if got, ok := registry.Lookup("CTS"); !ok || got.AssetID != testAssetCents {
+		t.Errorf("Lookup() = %+v", got)
+	}
+	if _, ok := registry.Lookup(string(testAssetR)); ok {
+		t.Errorf("Lookup() found unknown asset")
+	}
+
+	if got := registry.Precision(testAssetCents); got != 2 {
+		t.Errorf("Precision() = %v, want 2", got)
+	}
+	if got := registry.Precision(testAssetR); got != AmountPrecision {
+		t.Errorf("Precision() = %v, want %v", got, AmountPrecision)
+	}
+}
+
+// not parallel, replace default registry
+func TestProposalInfo_ValidWithRegistry(t *testing.T) {
+	registry, err := ParseAssetRegistry([]byte(testRegistryIndex))
+	if err != nil {
+		t.Fatalf("ParseAssetRegistry() error = %v", err)
+	}
+	SetAssetRegistry(registry)
+	defer SetAssetRegistry(nil)
+
+	tests := []struct {
+		name     string
+		proposal ProposalInfo
+		want     bool
+		wantArgs []string
+	}{
+		{"ids", ProposalInfo{testAssetP, 1000, testAssetCents, 150}, true, []string{string(testAssetP), "0.00001000", string(testAssetCents), "1.50"}},
+		{"tickers", ProposalInfo{"USDt", 1000, "cts", 150}, true, []string{string(testAssetP), "0.00001000", string(testAssetCents), "1.50"}},
+		{"unknownAsset", ProposalInfo{testAssetR, 1000, testAssetCents, 1}, true, []string{string(testAssetR), "0.00001000", string(testAssetCents), "0.01"}},
+
+		{"zeroAmount", ProposalInfo{testAssetP, 1000, testAssetCents, 0}, false, []string{string(testAssetP), "0.00001000", string(testAssetCents), "0.00"}},
+		{"unknownTicker", ProposalInfo{"LCAD", 1000, testAssetCents, 150}, false, []string{"LCAD", "0.00001000", string(testAssetCents), "1.50"}},
+		{"sameAsset", ProposalInfo{testAssetP, 1000, "USDt", 1000}, false, []string{string(testAssetP), "0.00001000", string(testAssetP), "0.00001000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.proposal.Valid(); got != tt.want {
+				t.Errorf("ProposalInfo.Valid() = %v, want %v", got, tt.want)
+			}
+			if got := tt.proposal.Args(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("ProposalInfo.Args() = %v, want %v", got, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,440 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"time"
+
+	"github.com/condensat/bank-core"
+)
+
+// Requests and responses fields are a subset of SwapProposal fields with the same names,
+// gob match fields by name so legacy SwapProposal messages are decoded with per operation types,
+// and legacy clients decode per operation responses as SwapProposal.
+
+type CreateProposalRequest struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string // empty for service default wallet
+	Address   ConfidentialAddress
+	Proposal  ProposalInfo
+	FeeRate   Amount // satoshi/Kb, zero for network default
+}
+
+type CreateProposalResponse struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+	Expiry    time.Time
+	Error     *SwapError
+}
+
+type InfoProposalRequest struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+}
+
+type InfoProposalResponse struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+	Info      *SwapInfo
+	Error     *SwapError
+}
+
+type AcceptProposalRequest struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Address   ConfidentialAddress
+	Payload   Payload
+	FeeRate   Amount    // satoshi/Kb, zero for network default
+	Expiry    time.Time // proposal expiry, can only shorten the recorded expiry
+}
+
+type AcceptProposalResponse struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+	Expiry    time.Time
+	Error     *SwapError
+}
+
+type FinalizeProposalRequest struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+
+	SkipBroadcast bool // return the signed transaction without sending it
+}
+
+type FinalizeProposalResponse struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+	Expiry    time.Time
+	Error     *SwapError
+}
+
+type CancelProposalRequest struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+	FeeRate   Amount // satoshi/Kb, zero for network default
+}
+
+type CancelProposalResponse struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+	Error     *SwapError
+}
+
+type BroadcastRequest struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+}
+
+type BroadcastResponse struct {
+	Version   int
+	Timestamp time.Time
+	SwapID    uint64
+	Wallet    string
+	Payload   Payload
+	Error     *SwapError
+}
+
+// Validate return ErrInvalidAddress or ErrInvalidProposal
+func (p *CreateProposalRequest) Validate(network Network) error {
+	if err := p.Address.Validate(network); err != nil {
+		return ErrInvalidAddress
+	}
+	if !p.Proposal.Valid() {
+		return ErrInvalidProposal
+	}
+	return nil
+}
+
+// Validate return ErrInvalidPayload
+func (p *InfoProposalRequest) Validate(network Network) error {
+	return validatePayload(p.Payload)
+}
+
+// Validate return ErrInvalidAddress or ErrInvalidPayload
+func (p *AcceptProposalRequest) Validate(network Network) error {
+	if err := p.Address.Validate(network); err != nil {
+		return ErrInvalidAddress
+	}
+	return validatePayload(p.Payload)
+}
+
+// Validate return ErrInvalidPayload
+func (p *FinalizeProposalRequest) Validate(network Network) error {
+	return validatePayload(p.Payload)
+}
+
+// Validate return ErrInvalidPayload
+func (p *CancelProposalRequest) Validate(network Network) error {
+	return validatePayload(p.Payload)
+}
+
+// Validate return ErrInvalidPayload if payload is not a finalized transaction
+func (p *BroadcastRequest) Validate(network Network) error {
+	if _, err := p.Payload.Finalized(); err != nil {
+		return ErrInvalidPayload
+	}
+	return nil
+}
+
+func validatePayload(payload Payload) error {
+	if !payload.Valid() {
+		return ErrInvalidPayload
+	}
+	return nil
+}
+
+// CreateProposalRequest return the legacy message as create request
+func (p *SwapProposal) CreateProposalRequest() CreateProposalRequest {
+	return CreateProposalRequest{
+		Version:   p.Version,
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Address:   p.Address,
+		Proposal:  p.Proposal,
+		FeeRate:   p.FeeRate,
+	}
+}
+
+// AcceptProposalRequest return the legacy message as accept request
+func (p *SwapProposal) AcceptProposalRequest() AcceptProposalRequest {
+	return AcceptProposalRequest{
+		Version:   p.Version,
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Address:   p.Address,
+		Payload:   p.Payload,
+		FeeRate:   p.FeeRate,
+		Expiry:    p.Expiry,
+	}
+}
+
+// CreateProposalResponse return the operation result as create response
+func (p *SwapProposal) CreateProposalResponse() CreateProposalResponse {
+	return CreateProposalResponse{
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Payload:   p.Payload,
+		Expiry:    p.Expiry,
+		Error:     p.Error,
+	}
+}
+
+// InfoProposalResponse return the operation result as info response
+func (p *SwapProposal) InfoProposalResponse() InfoProposalResponse {
+	return InfoProposalResponse{
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Payload:   p.Payload,
+		Info:      p.Info,
+		Error:     p.Error,
+	}
+}
+
+// AcceptProposalResponse return the operation result as accept response
+func (p *SwapProposal) AcceptProposalResponse() AcceptProposalResponse {
+	return AcceptProposalResponse{
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Payload:   p.Payload,
+		Expiry:    p.Expiry,
+		Error:     p.Error,
+	}
+}
+
+// FinalizeProposalResponse return the operation result as finalize response
+func (p *SwapProposal) FinalizeProposalResponse() FinalizeProposalResponse {
+	return FinalizeProposalResponse{
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Payload:   p.Payload,
+		Expiry:    p.Expiry,
+		Error:     p.Error,
+	}
+}
+
+// CancelProposalResponse return the operation result as cancel response
+func (p *SwapProposal) CancelProposalResponse() CancelProposalResponse {
+	return CancelProposalResponse{
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Payload:   p.Payload,
+		Error:     p.Error,
+	}
+}
+
+// BroadcastResponse return the operation result as broadcast response
+func (p *SwapProposal) BroadcastResponse() BroadcastResponse {
+	return BroadcastResponse{
+		Timestamp: p.Timestamp,
+		SwapID:    p.SwapID,
+		Wallet:    p.Wallet,
+		Payload:   p.Payload,
+		Error:     p.Error,
+	}
+}
+
+// decodeLegacyProposal decode legacy float amounts messages
+func decodeLegacyProposal(data []byte) (SwapProposal, error) {
+	var legacy legacySwapProposal
+	if err := legacy.Decode(data); err != nil {
+		return SwapProposal{}, err
+	}
+	return legacy.SwapProposal(), nil
+}
+
+// BankObject interface
+
+func (p *CreateProposalRequest) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *CreateProposalRequest) Decode(data []byte) error {
+	err := bank.DecodeObject(data, bank.BankObject(p))
+	if err == nil {
+		return nil
+	}
+
+	// fallback to legacy float amounts
+	legacy, errLegacy := decodeLegacyProposal(data)
+	if errLegacy != nil {
+		return err
+	}
+	*p = legacy.CreateProposalRequest()
+	return nil
+}
+
+func (p *CreateProposalResponse) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *CreateProposalResponse) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *InfoProposalRequest) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *InfoProposalRequest) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *InfoProposalResponse) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *InfoProposalResponse) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *AcceptProposalRequest) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *AcceptProposalRequest) Decode(data []byte) error {
+	err := bank.DecodeObject(data, bank.BankObject(p))
+	if err == nil {
+		return nil
+	}
+
+	// fallback to legacy float amounts
+	legacy, errLegacy := decodeLegacyProposal(data)
+	if errLegacy != nil {
+		return err
+	}
+	*p = legacy.AcceptProposalRequest()
+	return nil
+}
+
+func (p *AcceptProposalResponse) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *AcceptProposalResponse) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *FinalizeProposalRequest) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *FinalizeProposalRequest) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *FinalizeProposalResponse) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *FinalizeProposalResponse) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *CancelProposalRequest) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *CancelProposalRequest) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *CancelProposalResponse) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *CancelProposalResponse) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *BroadcastRequest) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *BroadcastRequest) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func (p *BroadcastResponse) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
+	return bank.EncodeObject(p)
+}
+
+func (p *BroadcastResponse) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
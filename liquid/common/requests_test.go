@@ -0,0 +1,249 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+)
+
+func TestCreateProposalRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	proposal := ProposalInfo{
+		ProposerAsset:  testAssetP,
+		ProposerAmount: 1000,
+		ReceiverAsset:  testAssetR,
+		ReceiverAmount: 1400,
+	}
+	sameAsset := proposal
+	sameAsset.ReceiverAsset = testAssetP
+
+	tests := []struct {
+		name    string
+		request CreateProposalRequest
+		wantErr error
+	}{
+		{"valid", CreateProposalRequest{Address: testAddressLiquid, Proposal: proposal}, nil},
+		{"noAddress", CreateProposalRequest{Proposal: proposal}, ErrInvalidAddress},
+		{"wrongNetwork", CreateProposalRequest{Address: testAddressTestnet, Proposal: proposal}, ErrInvalidAddress},
+		{"noProposal", CreateProposalRequest{Address: testAddressLiquid}, ErrInvalidProposal},
+		{"sameAsset", CreateProposalRequest{Address: testAddressLiquid, Proposal: sameAsset}, ErrInvalidProposal},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.request.Validate(NetworkLiquid); err != tt.wantErr {
+				t.Errorf("CreateProposalRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAcceptProposalRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	payload, _ := EncodePayload(testProposalDocument(), PayloadBase64)
+
+	tests := []struct {
+		name    string
+		request AcceptProposalRequest
+		wantErr error
+	}{
+		{"valid", AcceptProposalRequest{Address: testAddressLiquid, Payload: payload}, nil},
+		{"noAddress", AcceptProposalRequest{Payload: payload}, ErrInvalidAddress},
+		{"noPayload", AcceptProposalRequest{Address: testAddressLiquid}, ErrInvalidPayload},
+		{"invalidPayload", AcceptProposalRequest{Address: testAddressLiquid, Payload: "payload"}, ErrInvalidPayload},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.request.Validate(NetworkLiquid); err != tt.wantErr {
+				t.Errorf("AcceptProposalRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayloadRequests_Validate(t *testing.T) {
+	t.Parallel()
+
+	proposal, _ := EncodePayload(testProposalDocument(), PayloadBase64)
+	finalized, _ := EncodePayload(&FinalizedDocument{TxID: testMessageTxID, Tx: "0200"}, PayloadBase64)
+
+	type validator interface {
+		Validate(network Network) error
+	}
+
+	tests := []struct {
+		name    string
+		request validator
+		wantErr error
+	}{
+		{"info", &InfoProposalRequest{Payload: proposal}, nil},
+		{"infoEmpty", &InfoProposalRequest{}, ErrInvalidPayload},
+		{"finalize", &FinalizeProposalRequest{Payload: proposal}, nil},
+		{"finalizeEmpty", &FinalizeProposalRequest{}, ErrInvalidPayload},
+		{"cancel", &CancelProposalRequest{Payload: proposal}, nil},
+		{"cancelEmpty", &CancelProposalRequest{}, ErrInvalidPayload},
+		{"broadcast", &BroadcastRequest{Payload: finalized}, nil},
+		{"broadcastProposal", &BroadcastRequest{Payload: proposal}, ErrInvalidPayload},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.request.Validate(NetworkLiquid); err != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequests_DecodeLegacySwapProposal(t *testing.T) {
+	t.Parallel()
+
+	legacy := SwapProposal{
+		Version:   SchemaVersion,
+		Timestamp: testMessageTimestamp,
+		SwapID:    42,
+		Address:   testAddressLiquid,
+		Proposal: ProposalInfo{
+			ProposerAsset:  testAssetP,
+			ProposerAmount: 1000,
+			ReceiverAsset:  testAssetR,
+			ReceiverAmount: 1400,
+		},
+		FeeRate: DefaultFeeRate,
+		Payload: "payload",
+		Wallet:  "treasury",
+		Expiry:  testMessageTimestamp.Add(15 * time.Minute),
+
+		SkipBroadcast: true,
+	}
+	data, err := legacy.Encode()
+	if err != nil {
+		t.Fatalf("SwapProposal.Encode() error = %v", err)
+	}
+
+	var create CreateProposalRequest
+	if err := create.Decode(data); err != nil {
+		t.Fatalf("CreateProposalRequest.Decode() error = %v", err)
+	}
+	if create != legacy.CreateProposalRequest() {
+		t.Errorf("CreateProposalRequest.Decode() = %+v", create)
+	}
+
+	var accept AcceptProposalRequest
+	if err := accept.Decode(data); err != nil {
+		t.Fatalf("AcceptProposalRequest.Decode() error = %v", err)
+	}
+	if accept != legacy.AcceptProposalRequest() {
+		t.Errorf("AcceptProposalRequest.Decode() = %+v", accept)
+	}
+
+	var finalize FinalizeProposalRequest
+	if err := finalize.Decode(data); err != nil {
+		t.Fatalf("FinalizeProposalRequest.Decode() error = %v", err)
+	}
+	if finalize.SwapID != 42 || finalize.Wallet != "treasury" || finalize.Payload != "payload" || !finalize.SkipBroadcast {
+		t.Errorf("FinalizeProposalRequest.Decode() = %+v", finalize)
+	}
+
+	// recorded v1 client request
+	var v1 CreateProposalRequest
+	if err := v1.Decode(readMessage(t, "v1_create_request.gob")); err != nil {
+		t.Fatalf("CreateProposalRequest.Decode() error = %v", err)
+	}
+	if MessageVersion(v1.Version) != SchemaVersion1 || v1.Validate(NetworkLiquid) != nil {
+		t.Errorf("CreateProposalRequest.Decode() invalid v1 request %+v", v1)
+	}
+}
+
+func TestRequests_DecodeLegacyAmounts(t *testing.T) {
+	t.Parallel()
+
+	legacy := legacySwapProposal{
+		Timestamp: time.Unix(1600000000, 0).UTC(),
+		SwapID:    42,
+		Address:   "address",
+		Proposal: legacyProposalInfo{
+			ProposerAsset:  "assetP",
+			ProposerAmount: 0.00001,
+			ReceiverAsset:  "assetR",
+			ReceiverAmount: 0.000014,
+		},
+		FeeRate: 150 / 100000000.0,
+		Payload: "payload",
+	}
+
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(&legacy); err != nil {
+		t.Fatalf("gob.Encode() error = %v", err)
+	}
+
+	var create CreateProposalRequest
+	if err := create.Decode(buffer.Bytes()); err != nil {
+		t.Fatalf("CreateProposalRequest.Decode() error = %v", err)
+	}
+	if create.SwapID != 42 || create.Proposal.ProposerAmount != 1000 || create.Proposal.ReceiverAmount != 1400 || create.FeeRate != DefaultFeeRate {
+		t.Errorf("CreateProposalRequest.Decode() wrong amounts %+v", create)
+	}
+
+	var accept AcceptProposalRequest
+	if err := accept.Decode(buffer.Bytes()); err != nil {
+		t.Fatalf("AcceptProposalRequest.Decode() error = %v", err)
+	}
+	if accept.Payload != "payload" || accept.FeeRate != DefaultFeeRate {
+		t.Errorf("AcceptProposalRequest.Decode() = %+v", accept)
+	}
+}
+
+func TestResponses_LegacyClient(t *testing.T) {
+	t.Parallel()
+
+	result := SwapProposal{
+		Timestamp: testMessageTimestamp,
+		SwapID:    42,
+		Payload:   "payload",
+		Info:      &SwapInfo{},
+		Wallet:    "treasury",
+		Error:     NewSwapError(ErrWalletBusy),
+		Expiry:    testMessageTimestamp.Add(15 * time.Minute),
+	}
+
+	info := result.InfoProposalResponse()
+	data, err := info.Encode()
+	if err != nil {
+		t.Fatalf("InfoProposalResponse.Encode() error = %v", err)
+	}
+
+	var got SwapProposal
+	if err := got.Decode(data); err != nil {
+		t.Fatalf("SwapProposal.Decode() error = %v", err)
+	}
+	if got.Version != SchemaVersion || got.SwapID != 42 || got.Payload != "payload" || got.Info == nil {
+		t.Errorf("SwapProposal.Decode() = %+v", got)
+	}
+	if got.Error == nil || got.Error.Code != ErrorCodeWalletBusy {
+		t.Errorf("SwapProposal.Decode() wrong error %+v", got.Error)
+	}
+
+	// recorded v1 service reply
+	var create CreateProposalResponse
+	if err := create.Decode(readMessage(t, "v1_create_reply.gob")); err != nil {
+		t.Fatalf("CreateProposalResponse.Decode() error = %v", err)
+	}
+	if MessageVersion(create.Version) != SchemaVersion1 || create.SwapID != 42 || create.Payload != "cHNldP8=" || create.Error != nil {
+		t.Errorf("CreateProposalResponse.Decode() = %+v", create)
+	}
+}
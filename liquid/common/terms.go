@@ -0,0 +1,67 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"errors"
+)
+
+var (
+	ErrTermsMismatch = errors.New("Swap Terms Mismatch")
+)
+
+// ReservedInput is a proposal wallet unspent
+type ReservedInput struct {
+	Outpoint
+	Asset  AssetID
+	Amount Amount
+}
+
+// SwapTerms is the proposal as recorded by the proposer,
+// the accepted transaction is checked against before signing
+type SwapTerms struct {
+	Proposal ProposalInfo
+	// Inputs are the only wallet unspents the transaction can spend
+	Inputs []ReservedInput
+}
+
+// Check return ErrTermsMismatch if the wallet side of the transaction does not match the terms.
+// walletInputs are the transaction inputs owned by the wallet,
+// received are the wallet outputs amounts by asset.
+func (p *SwapTerms) Check(walletInputs []Outpoint, received map[AssetID]Amount) error {
+	reserved := make(map[Outpoint]bool)
+	spent := make(map[AssetID]Amount)
+	for _, input := range p.Inputs {
+		reserved[input.Outpoint] = true
+		spent[input.Asset] += input.Amount
+	}
+
+	// all reserved inputs and nothing else
+	if len(walletInputs) != len(reserved) {
+		return ErrTermsMismatch
+	}
+	for _, outpoint := range walletInputs {
+		if !reserved[outpoint] {
+			return ErrTermsMismatch
+		}
+		delete(reserved, outpoint)
+	}
+
+	proposal := p.Proposal
+	if received[proposal.ReceiverAsset] < proposal.ReceiverAmount {
+		return ErrTermsMismatch
+	}
+	// only the proposer amount can leave the wallet
+	for asset, amount := range spent {
+		var allowed Amount
+		if asset == proposal.ProposerAsset {
+			allowed = proposal.ProposerAmount
+		}
+		if amount-received[asset] > allowed {
+			return ErrTermsMismatch
+		}
+	}
+	return nil
+}
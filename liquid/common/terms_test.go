@@ -0,0 +1,52 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"testing"
+)
+
+func TestSwapTerms_Check(t *testing.T) {
+	t.Parallel()
+
+	reserved := Outpoint{TxID: "aa", Vout: 0}
+	other := Outpoint{TxID: "bb", Vout: 1}
+	terms := SwapTerms{
+		Proposal: ProposalInfo{
+			ProposerAsset:  testAssetP,
+			ProposerAmount: 1000,
+			ReceiverAsset:  testAssetR,
+			ReceiverAmount: 1400,
+		},
+		Inputs: []ReservedInput{
+			{Outpoint: reserved, Asset: testAssetP, Amount: 1500},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		walletInputs []Outpoint
+		received     map[AssetID]Amount
+		wantErr      error
+	}{
+		{"valid", []Outpoint{reserved}, map[AssetID]Amount{testAssetR: 1400, testAssetP: 500}, nil},
+		{"lowerReceived", []Outpoint{reserved}, map[AssetID]Amount{testAssetR: 1399, testAssetP: 500}, ErrTermsMismatch},
+		{"lowerChange", []Outpoint{reserved}, map[AssetID]Amount{testAssetR: 1400, testAssetP: 499}, ErrTermsMismatch},
+		{"otherAsset", []Outpoint{reserved}, map[AssetID]Amount{testAssetCents: 1400, testAssetP: 500}, ErrTermsMismatch},
+		{"missingInput", nil, map[AssetID]Amount{testAssetR: 1400}, ErrTermsMismatch},
+		{"otherInput", []Outpoint{reserved, other}, map[AssetID]Amount{testAssetR: 1400, testAssetP: 500}, ErrTermsMismatch},
+		{"duplicateInput", []Outpoint{reserved, reserved}, map[AssetID]Amount{testAssetR: 1400, testAssetP: 500}, ErrTermsMismatch},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := terms.Check(tt.walletInputs, tt.received); err != tt.wantErr {
+				t.Errorf("SwapTerms.Check() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	RequestTimeoutsKey = "Key.RequestTimeoutsKey"
+
+	DefaultRequestTimeout = 20 * time.Second
+
+	// RequestTimeoutMargin let clients receive the handler timeout reply
+	RequestTimeoutMargin = 2 * time.Second
+)
+
+var (
+	ErrInvalidRequestTimeout = errors.New("Invalid Request Timeout")
+	ErrRequestTimeout        = errors.New("Request Timeout")
+)
+
+// RequestTimeouts is the timeout per swap operation
+type RequestTimeouts struct {
+	defaultTimeout time.Duration
+	operations     map[string]time.Duration
+}
+
+func NewRequestTimeouts(defaultTimeout time.Duration) *RequestTimeouts {
+	if defaultTimeout <= 0 {
+		defaultTimeout = DefaultRequestTimeout
+	}
+	return &RequestTimeouts{
+		defaultTimeout: defaultTimeout,
+		operations:     make(map[string]time.Duration),
+	}
+}
+
+// ParseRequestTimeouts parse comma separated operation timeout, ex: "CreateProposal=30s,Balances=5s"
+// operations are subjects names without prefix
+func ParseRequestTimeouts(defaultTimeout time.Duration, operations string) (*RequestTimeouts, error) {
+	result := NewRequestTimeouts(defaultTimeout)
+
+	for _, entry := range strings.Split(operations, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		toks := strings.Split(entry, "=")
+		if len(toks) != 2 {
+			return nil, ErrInvalidRequestTimeout
+		}
+		subject := chanPrefix + "Swap." + strings.TrimSpace(toks[0])
+		if !swapOperation(subject) {
+			return nil, ErrInvalidRequestTimeout
+		}
+		timeout, err := time.ParseDuration(toks[1])
+		if err != nil || timeout <= 0 {
+			return nil, ErrInvalidRequestTimeout
+		}
+		result.SetTimeout(subject, timeout)
+	}
+
+	return result, nil
+}
+
+func swapOperation(subject string) bool {
+	switch subject {
+	case SwapCreateProposalSubject,
+		SwapInfoProposalSubject,
+		SwapFinalizeProposalSubject,
+		SwapAcceptProposalSubject,
+		SwapCancelProposalSubject,
+		SwapBalancesSubject,
+		SwapBroadcastSubject,
+		SwapQueueStatusSubject:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (p *RequestTimeouts) SetTimeout(subject string, timeout time.Duration) {
+	p.operations[subject] = timeout
+}
+
+// Timeout return the operation timeout or the default timeout
+func (p *RequestTimeouts) Timeout(subject string) time.Duration {
+	if timeout, ok := p.operations[subject]; ok {
+		return timeout
+	}
+	return p.defaultTimeout
+}
+
+// Max return the longest operation timeout
+func (p *RequestTimeouts) Max() time.Duration {
+	result := p.defaultTimeout
+	for _, timeout := range p.operations {
+		if timeout > result {
+			result = timeout
+		}
+	}
+	return result
+}
+
+// RequestTimeoutsContext set operations timeouts used by clients and handlers
+func RequestTimeoutsContext(ctx context.Context, timeouts *RequestTimeouts) context.Context {
+	return context.WithValue(ctx, RequestTimeoutsKey, timeouts)
+}
+
+// RequestTimeoutsFromContext return DefaultRequestTimeout for all operations if not set
+func RequestTimeoutsFromContext(ctx context.Context) *RequestTimeouts {
+	switch timeouts := ctx.Value(RequestTimeoutsKey).(type) {
+	case *RequestTimeouts:
+		return timeouts
+
+	default:
+		return NewRequestTimeouts(DefaultRequestTimeout)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRequestTimeouts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		operations string
+		subject    string
+		want       time.Duration
+		wantErr    bool
+	}{
+		{"empty", "", SwapCreateProposalSubject, time.Minute, false},
+		{"operation", "CreateProposal=30s", SwapCreateProposalSubject, 30 * time.Second, false},
+		{"list", "CreateProposal=30s, Balances=5s", SwapBalancesSubject, 5 * time.Second, false},
+		{"otherOperation", "CreateProposal=30s", SwapFinalizeProposalSubject, time.Minute, false},
+
+		{"unknownOperation", "Transfer=5s", "", 0, true},
+		{"invalidDuration", "Balances=5", "", 0, true},
+		{"negativeDuration", "Balances=-5s", "", 0, true},
+		{"invalidEntry", "Balances", "", 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseRequestTimeouts(time.Minute, tt.operations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRequestTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if timeout := got.Timeout(tt.subject); timeout != tt.want {
+				t.Errorf("RequestTimeouts.Timeout() = %v, want %v", timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestTimeouts_Max(t *testing.T) {
+	t.Parallel()
+
+	if got := RequestTimeoutsFromContext(context.Background()).Max(); got != DefaultRequestTimeout {
+		t.Errorf("RequestTimeouts.Max() = %v, want %v", got, DefaultRequestTimeout)
+	}
+
+	timeouts := NewRequestTimeouts(10 * time.Second)
+	timeouts.SetTimeout(SwapFinalizeProposalSubject, time.Minute)
+	timeouts.SetTimeout(SwapBalancesSubject, time.Second)
+	if got := timeouts.Max(); got != time.Minute {
+		t.Errorf("RequestTimeouts.Max() = %v, want %v", got, time.Minute)
+	}
+}
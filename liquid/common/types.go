@@ -8,23 +8,21 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"strings"
 	"time"
 
 	"github.com/condensat/bank-core"
-	"github.com/condensat/bank-core/utils"
 )
 
 const (
 	AssetIDLength = 64
+	TxIDLength    = 64
 
-	DefaultFeeRate = 150 / 100000000.0 // BTC/Kb
-	MinumumFeeRate = 150 / 100000000.0 // BTC/Kb
+	DefaultFeeRate = Amount(150) // satoshi/Kb
+	MinumumFeeRate = Amount(150) // satoshi/Kb
 
-	AmountPrecision       = 8
-	AmountPrecisionFormat = "%.8f"
+	AmountPrecision = 8
 )
 
 var (
@@ -39,48 +37,102 @@ type Payload string
 
 type ProposalInfo struct {
 	ProposerAsset  AssetID
-	ProposerAmount float64
+	ProposerAmount Amount
 	ReceiverAsset  AssetID
-	ReceiverAmount float64
+	ReceiverAmount Amount
 }
 
+// SwapProposal is the legacy message of all swap operations and the stored operation result,
+// clients and handlers use per operation requests and responses
 type SwapProposal struct {
+	Version   int // schema version, zero for SchemaVersion1
 	Timestamp time.Time
 	SwapID    uint64
 	Address   ConfidentialAddress
 	Proposal  ProposalInfo
-	FeeRate   float64
+	FeeRate   Amount // satoshi/Kb
 	Payload   Payload
+	Info      *SwapInfo
+	Wallet    string // empty for service default wallet
+	Error     *SwapError
+	Expiry    time.Time // proposal expiry, zero for no expiry
+
+	SkipBroadcast bool // finalize return the signed transaction without sending it
 }
 
+// Args return liquidswap-cli arguments, amounts are formatted with asset precision
 func (p *ProposalInfo) Args() []string {
-	proposerAsset := string(p.ProposerAsset)
-	receiverAsset := string(p.ReceiverAsset)
+	registry := DefaultAssetRegistry()
 
-	proposerAmount := fmt.Sprintf(AmountPrecisionFormat, utils.ToFixed(p.ProposerAmount, AmountPrecision))
-	receiverAmount := fmt.Sprintf(AmountPrecisionFormat, utils.ToFixed(p.ReceiverAmount, AmountPrecision))
+	proposal, err := p.Resolve(registry)
+	if err != nil {
+		proposal = *p
+	}
 
 	return []string{
-		proposerAsset,
-		proposerAmount,
-		receiverAsset,
-		receiverAmount,
+		string(proposal.ProposerAsset),
+		proposal.ProposerAmount.Format(registry.Precision(proposal.ProposerAsset)),
+		string(proposal.ReceiverAsset),
+		proposal.ReceiverAmount.Format(registry.Precision(proposal.ReceiverAsset)),
 	}
 }
 
+// Resolve return the proposal with assets tickers replaced by AssetID
+func (p *ProposalInfo) Resolve(registry *AssetRegistry) (ProposalInfo, error) {
+	proposerAsset, err := registry.Resolve(p.ProposerAsset)
+	if err != nil {
+		return ProposalInfo{}, err
+	}
+	receiverAsset, err := registry.Resolve(p.ReceiverAsset)
+	if err != nil {
+		return ProposalInfo{}, err
+	}
+
+	result := *p
+	result.ProposerAsset = proposerAsset
+	result.ReceiverAsset = receiverAsset
+	return result, nil
+}
+
+// Valid check assets with the asset registry and amounts range
 func (p *ProposalInfo) Valid() bool {
-	return len(p.ProposerAsset) == AssetIDLength &&
-		len(p.ReceiverAsset) == AssetIDLength &&
-		p.ProposerAmount > 0.0 &&
-		p.ReceiverAmount > 0.0
+	registry := DefaultAssetRegistry()
+
+	proposal, err := p.Resolve(registry)
+	if err != nil {
+		return false
+	}
+
+	return proposal.ProposerAsset != proposal.ReceiverAsset &&
+		validAmount(proposal.ProposerAmount) &&
+		validAmount(proposal.ReceiverAmount)
+}
+
+// validAmount is in on-chain units, any amount is valid for all asset precisions
+func validAmount(amount Amount) bool {
+	return amount > 0 && amount <= MaxAmount
 }
 
 func (p *SwapProposal) Encode() ([]byte, error) {
+	if p.Version == 0 {
+		p.Version = SchemaVersion
+	}
 	return bank.EncodeObject(p)
 }
 
 func (p *SwapProposal) Decode(data []byte) error {
-	return bank.DecodeObject(data, bank.BankObject(p))
+	err := bank.DecodeObject(data, bank.BankObject(p))
+	if err == nil {
+		return nil
+	}
+
+	// fallback to legacy float amounts
+	var legacy legacySwapProposal
+	if errLegacy := legacy.Decode(data); errLegacy != nil {
+		return err
+	}
+	*p = legacy.SwapProposal()
+	return nil
 }
 
 func (payload Payload) Stdin() io.Reader {
@@ -15,15 +15,21 @@ func TestProposalInfo_Args(t *testing.T) {
 
 	ref1 := ProposalInfo{
 		ProposerAsset:  "assetP",
-		ProposerAmount: 0.1234567811111,
+		ProposerAmount: 12345678,
 		ReceiverAsset:  "assetR",
-		ReceiverAmount: 3.141592653589793,
+		ReceiverAmount: 314159265,
 	}
 	ref2 := ProposalInfo{
 		ProposerAsset:  "assetP",
-		ProposerAmount: -0.123456789,
+		ProposerAmount: -12345679,
 		ReceiverAsset:  "assetR",
-		ReceiverAmount: -3.141592653589793,
+		ReceiverAmount: -314159265,
+	}
+	ref3 := ProposalInfo{
+		ProposerAsset:  "assetP",
+		ProposerAmount: 2100000000000000,
+		ReceiverAsset:  "assetR",
+		ReceiverAmount: 1,
 	}
 
 	type fields struct {
@@ -38,6 +44,7 @@ func TestProposalInfo_Args(t *testing.T) {
 
 		{"ref1", fields{ref1}, []string{"assetP", "0.12345678", "assetR", "3.14159265"}},
 		{"ref2", fields{ref2}, []string{"assetP", "-0.12345679", "assetR", "-3.14159265"}},
+		{"ref3", fields{ref3}, []string{"assetP", "21000000.00000000", "assetR", "0.00000001"}},
 	}
 	for _, tt := range tests {
 		tt := tt // capture range variable
@@ -0,0 +1,72 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	SchemaVersionKey = "Key.SchemaVersionKey"
+
+	// SchemaVersion1 messages have no version field, subjects are not versioned
+	SchemaVersion1 = 1
+	SchemaVersion2 = 2
+
+	SchemaVersion = SchemaVersion2
+)
+
+var (
+	ErrUnsupportedSchemaVersion = errors.New("Unsupported Schema Version")
+
+	// SupportedSchemaVersions are served by the swap service
+	SupportedSchemaVersions = []int{SchemaVersion1, SchemaVersion2}
+)
+
+// MessageVersion return SchemaVersion1 for messages without version
+func MessageVersion(version int) int {
+	if version <= 0 {
+		return SchemaVersion1
+	}
+	return version
+}
+
+// CheckSchemaVersion return ErrUnsupportedSchemaVersion for newer messages
+func CheckSchemaVersion(version int) error {
+	if MessageVersion(version) > SchemaVersion {
+		return ErrUnsupportedSchemaVersion
+	}
+	return nil
+}
+
+// SubjectVersion return the subject for schema version
+// Condensat.Liquid.Swap.CreateProposal become Condensat.Liquid.v2.Swap.CreateProposal
+func SubjectVersion(subject string, version int) string {
+	if MessageVersion(version) == SchemaVersion1 || !strings.HasPrefix(subject, chanPrefix) {
+		return subject
+	}
+	return fmt.Sprintf("%sv%d.%s", chanPrefix, version, strings.TrimPrefix(subject, chanPrefix))
+}
+
+// SchemaVersionContext set the schema version used by clients
+func SchemaVersionContext(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, SchemaVersionKey, version)
+}
+
+// SchemaVersionFromContext return SchemaVersion if not set
+func SchemaVersionFromContext(ctx context.Context) int {
+	if version, ok := ctx.Value(SchemaVersionKey).(int); ok && version > 0 {
+		return version
+	}
+	return SchemaVersion
+}
+
+// RequestSubject return subject for the context network and schema version
+func RequestSubject(ctx context.Context, subject string) string {
+	return NetworkFromContext(ctx).Subject(SubjectVersion(subject, SchemaVersionFromContext(ctx)))
+}
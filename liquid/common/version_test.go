@@ -0,0 +1,260 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/condensat/bank-core"
+)
+
+var (
+	testMessageTimestamp = time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	testMessageTxID      = "6f17a9e140364b2d155b437c89d8237f9578f94142de016c027cfaf885edd6f2"
+)
+
+// v1ProposalInfo is the ProposalInfo schema used by version 1 clients
+type v1ProposalInfo struct {
+	ProposerAsset  AssetID
+	ProposerAmount float64
+	ReceiverAsset  AssetID
+	ReceiverAmount float64
+}
+
+// v1SwapProposal is the SwapProposal schema used by version 1 clients
+type v1SwapProposal struct {
+	Timestamp time.Time
+	SwapID    uint64
+	Address   ConfidentialAddress
+	Proposal  v1ProposalInfo
+	FeeRate   float64
+	Payload   Payload
+}
+
+func (p *v1SwapProposal) Encode() ([]byte, error) {
+	return bank.EncodeObject(p)
+}
+
+func (p *v1SwapProposal) Decode(data []byte) error {
+	return bank.DecodeObject(data, bank.BankObject(p))
+}
+
+func readMessage(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "messages", name))
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	return data
+}
+
+func TestSubjectVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		subject string
+		version int
+		want    string
+	}{
+		{"default", SwapCreateProposalSubject, 0, "Condensat.Liquid.Swap.CreateProposal"},
+		{"v1", SwapCreateProposalSubject, SchemaVersion1, "Condensat.Liquid.Swap.CreateProposal"},
+		{"v2", SwapCreateProposalSubject, SchemaVersion2, "Condensat.Liquid.v2.Swap.CreateProposal"},
+		{"event", SwapEventStateSubject("Broadcast"), SchemaVersion2, "Condensat.Liquid.v2.Swap.Event.Broadcast"},
+		{"other", "Other.Subject", SchemaVersion2, "Other.Subject"},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := SubjectVersion(tt.subject, tt.version); got != tt.want {
+				t.Errorf("SubjectVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestSubject(t *testing.T) {
+	t.Parallel()
+
+	ctx := NetworkContext(context.Background(), NetworkLiquidTestnet)
+
+	if got := RequestSubject(ctx, SwapInfoProposalSubject); got != "Condensat.Liquid.liquidtestnet.v2.Swap.InfoProposal" {
+		t.Errorf("RequestSubject() = %v, want v2 subject", got)
+	}
+
+	ctx = SchemaVersionContext(ctx, SchemaVersion1)
+	if got := RequestSubject(ctx, SwapInfoProposalSubject); got != "Condensat.Liquid.liquidtestnet.Swap.InfoProposal" {
+		t.Errorf("RequestSubject() = %v, want v1 subject", got)
+	}
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version int
+		wantErr bool
+	}{
+		{"unversioned", 0, false},
+		{"v1", SchemaVersion1, false},
+		{"current", SchemaVersion, false},
+		{"future", SchemaVersion + 1, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := CheckSchemaVersion(tt.version); (err != nil) != tt.wantErr {
+				t.Errorf("CheckSchemaVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaVersion_DecodeV1Request(t *testing.T) {
+	t.Parallel()
+
+	var request SwapProposal
+	if err := request.Decode(readMessage(t, "v1_create_request.gob")); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if got := MessageVersion(request.Version); got != SchemaVersion1 {
+		t.Errorf("MessageVersion() = %v, want %v", got, SchemaVersion1)
+	}
+	if request.SwapID != 42 || len(request.Wallet) != 0 || !request.Timestamp.Equal(testMessageTimestamp) {
+		t.Errorf("Invalid request header: %+v", request)
+	}
+	if request.Address != testAddressLiquid {
+		t.Errorf("Invalid Address = %v", request.Address)
+	}
+	want := ProposalInfo{
+		ProposerAsset:  testAssetP,
+		ProposerAmount: 100000000,
+		ReceiverAsset:  testAssetR,
+		ReceiverAmount: 140000000,
+	}
+	if request.Proposal != want {
+		t.Errorf("Invalid Proposal = %+v, want %+v", request.Proposal, want)
+	}
+	if request.FeeRate != 150 {
+		t.Errorf("Invalid FeeRate = %v", request.FeeRate)
+	}
+	if request.SkipBroadcast {
+		t.Errorf("v1 request must broadcast")
+	}
+}
+
+func TestSchemaVersion_DecodeV1Reply(t *testing.T) {
+	t.Parallel()
+
+	var reply SwapProposal
+	if err := reply.Decode(readMessage(t, "v1_create_reply.gob")); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := MessageVersion(reply.Version); got != SchemaVersion1 {
+		t.Errorf("MessageVersion() = %v, want %v", got, SchemaVersion1)
+	}
+	if reply.SwapID != 42 || reply.Payload != "cHNldP8=" || !reply.Timestamp.Equal(testMessageTimestamp) {
+		t.Errorf("Invalid reply = %+v", reply)
+	}
+	if reply.Error != nil {
+		t.Errorf("Invalid Error = %+v", reply.Error)
+	}
+}
+
+func TestSchemaVersion_V1ClientEncodeRequest(t *testing.T) {
+	t.Parallel()
+
+	request := v1SwapProposal{
+		Timestamp: testMessageTimestamp,
+		SwapID:    42,
+		Address:   testAddressLiquid,
+		Proposal: v1ProposalInfo{
+			ProposerAsset:  testAssetP,
+			ProposerAmount: 0.5,
+			ReceiverAsset:  testAssetR,
+			ReceiverAmount: 0.7,
+		},
+		FeeRate: 0.000002,
+	}
+	data, err := request.Encode()
+	if err != nil {
+		t.Fatalf("v1 Encode() error = %v", err)
+	}
+
+	var current SwapProposal
+	if err := current.Decode(data); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if current.Proposal.ProposerAmount != 50000000 || current.Proposal.ReceiverAmount != 70000000 || current.FeeRate != 200 {
+		t.Errorf("Invalid v1 request = %+v", current)
+	}
+}
+
+func TestSchemaVersion_V1ClientDecodeV2Reply(t *testing.T) {
+	t.Parallel()
+
+	reply := SwapProposal{
+		Timestamp:     testMessageTimestamp,
+		SwapID:        42,
+		Wallet:        "treasury",
+		Payload:       Payload("cHNldP8="),
+		Expiry:        testMessageTimestamp.Add(15 * time.Minute),
+		SkipBroadcast: true,
+	}
+	create := reply.CreateProposalResponse()
+	data, err := create.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var current SwapProposal
+	if err := current.Decode(data); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if current.Version != SchemaVersion || current.Payload != reply.Payload {
+		t.Errorf("Invalid v2 reply = %+v", current)
+	}
+
+	var old v1SwapProposal
+	if err := old.Decode(data); err != nil {
+		t.Fatalf("v1 Decode() error = %v", err)
+	}
+	if old.SwapID != reply.SwapID || old.Payload != reply.Payload || !old.Timestamp.Equal(reply.Timestamp) {
+		t.Errorf("Invalid v1 reply = %+v", old)
+	}
+}
+
+// v1 clients can not see reply errors, handlers must fail the request instead
+func TestSchemaVersion_V1ClientDecodeV2ErrorReply(t *testing.T) {
+	t.Parallel()
+
+	reply := SwapProposal{
+		Timestamp: testMessageTimestamp,
+		SwapID:    42,
+		Error:     NewSwapError(ErrWalletBusy),
+	}
+	create := reply.CreateProposalResponse()
+	data, err := create.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var old v1SwapProposal
+	if err := old.Decode(data); err != nil {
+		t.Fatalf("v1 Decode() error = %v", err)
+	}
+	if old.SwapID != reply.SwapID || len(old.Payload) != 0 {
+		t.Errorf("Invalid v1 reply = %+v", old)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	SwapWalletKey = "Key.SwapWalletKey"
+
+	DefaultWallet = "default"
+)
+
+var (
+	ErrWalletNotFound = errors.New("Wallet Not Found")
+)
+
+// SwapWalletContext set the wallet used for swap requests
+func SwapWalletContext(ctx context.Context, wallet string) context.Context {
+	return context.WithValue(ctx, SwapWalletKey, wallet)
+}
+
+// SwapWalletFromContext return empty string if no wallet is set
+func SwapWalletFromContext(ctx context.Context) string {
+	if wallet, ok := ctx.Value(SwapWalletKey).(string); ok {
+		return wallet
+	}
+	return ""
+}
@@ -0,0 +1,287 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+const (
+	PolicyAsset = "6f0279e9ed041c3d710a9f57d0c02928416460c4b722ae3457a11eec381c526d"
+)
+
+var (
+	ErrInsufficientFunds     = common.ErrInsufficientFunds
+	ErrInvalidTransaction    = errors.New("Invalid Transaction")
+	ErrIncompleteTransaction = errors.New("Incomplete Transaction")
+	ErrMissingInputs         = errors.New("Missing Inputs")
+)
+
+// Outpoint identify a transaction output
+type Outpoint struct {
+	TxID string `json:"txid"`
+	Vout int    `json:"vout"`
+}
+
+// Output is an unblinded fake transaction output, amount in satoshi
+type Output struct {
+	Address string `json:"address"`
+	Asset   string `json:"asset"`
+	Amount  int64  `json:"amount"`
+}
+
+// Transaction is the fake transaction, hex encoded json in payloads
+type Transaction struct {
+	Inputs  []Outpoint      `json:"inputs"`
+	Outputs []Output        `json:"outputs"`
+	Signed  map[string]bool `json:"signed,omitempty"`
+}
+
+func (p *Transaction) TxID() string {
+	unsigned := Transaction{
+		Inputs:  p.Inputs,
+		Outputs: p.Outputs,
+	}
+	data, _ := json.Marshal(&unsigned)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func (p *Transaction) Encode() string {
+	data, _ := json.Marshal(p)
+	return hex.EncodeToString(data)
+}
+
+func DecodeTransaction(txHex string) (Transaction, error) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return Transaction{}, ErrInvalidTransaction
+	}
+	var result Transaction
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Transaction{}, ErrInvalidTransaction
+	}
+	return result, nil
+}
+
+type unspent struct {
+	Outpoint
+	Output
+}
+
+// Chain is a shared in-memory ledger between fake engines
+type Chain struct {
+	sync.Mutex
+
+	unspents  map[Outpoint]Output
+	locked    map[Outpoint]bool
+	owners    map[string]string // address -> wallet
+	txs       map[string]Transaction
+	heights   map[string]int // txID -> block height, 0 in mempool
+	height    int
+	nextIndex int
+}
+
+func NewChain() *Chain {
+	return &Chain{
+		unspents: make(map[Outpoint]Output),
+		locked:   make(map[Outpoint]bool),
+		owners:   make(map[string]string),
+		txs:      make(map[string]Transaction),
+		heights:  make(map[string]int),
+	}
+}
+
+// Transaction return a broadcasted transaction
+func (p *Chain) Transaction(txID string) (Transaction, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	tx, ok := p.txs[txID]
+	return tx, ok
+}
+
+// Mine include mempool transactions in a new block, then add empty blocks
+func (p *Chain) Mine(blocks int) {
+	p.Lock()
+	defer p.Unlock()
+
+	if blocks <= 0 {
+		return
+	}
+	for txID, height := range p.heights {
+		if height == 0 {
+			p.heights[txID] = p.height + 1
+		}
+	}
+	p.height += blocks
+}
+
+// Drop remove a mempool transaction, as if evicted
+func (p *Chain) Drop(txID string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.heights[txID] == 0 {
+		delete(p.txs, txID)
+		delete(p.heights, txID)
+	}
+}
+
+// confirmations return ErrTxDropped for unknown transactions
+func (p *Chain) confirmations(txID string) (int, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if _, ok := p.txs[txID]; !ok {
+		return 0, common.ErrTxDropped
+	}
+	height := p.heights[txID]
+	if height == 0 {
+		return 0, nil
+	}
+	return p.height - height + 1, nil
+}
+
+func (p *Chain) newAddress(wallet string) string {
+	p.Lock()
+	defer p.Unlock()
+
+	p.nextIndex++
+
+	// deterministic valid confidential address
+	seed := sha256.Sum256([]byte(fmt.Sprintf("%s-address-%d", wallet, p.nextIndex)))
+	blindingKey := append([]byte{0x02}, seed[:]...)
+	program := sha256.Sum256(seed[:])
+	address, err := common.NewConfidentialAddress(common.DefaultNetwork, blindingKey, 0, program[:20])
+	if err != nil {
+		panic(err)
+	}
+
+	p.owners[string(address)] = wallet
+	return string(address)
+}
+
+func (p *Chain) fund(wallet, asset string, amount int64) {
+	address := p.newAddress(wallet)
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.nextIndex++
+	outpoint := Outpoint{
+		TxID: fmt.Sprintf("%064x", p.nextIndex),
+	}
+	p.unspents[outpoint] = Output{Address: address, Asset: asset, Amount: amount}
+}
+
+// walletUnspents return sorted wallet unspents for asset, all assets if asset is empty
+// locked unspents are not listed
+func (p *Chain) walletUnspents(wallet, asset string) []unspent {
+	p.Lock()
+	defer p.Unlock()
+
+	var result []unspent
+	for outpoint, output := range p.unspents {
+		if len(asset) > 0 && output.Asset != asset {
+			continue
+		}
+		if p.owners[output.Address] != wallet || p.locked[outpoint] {
+			continue
+		}
+		result = append(result, unspent{Outpoint: outpoint, Output: output})
+	}
+
+	// deterministic order, largest first
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Amount != result[j].Amount {
+			return result[i].Amount > result[j].Amount
+		}
+		return result[i].TxID < result[j].TxID
+	})
+	return result
+}
+
+// lock exclude outpoints from wallet unspents
+func (p *Chain) lock(outpoints []Outpoint, locked bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, outpoint := range outpoints {
+		if locked {
+			p.locked[outpoint] = true
+		} else {
+			delete(p.locked, outpoint)
+		}
+	}
+}
+
+func (p *Chain) owner(outpoint Outpoint) (string, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	output, ok := p.unspents[outpoint]
+	if !ok {
+		return "", false
+	}
+	return p.owners[output.Address], true
+}
+
+func (p *Chain) addressOwner(address string) string {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.owners[address]
+}
+
+// broadcast check transaction balance and signatures, then update unspents
+func (p *Chain) broadcast(tx Transaction) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	balances := make(map[string]int64)
+	for _, input := range tx.Inputs {
+		output, ok := p.unspents[input]
+		if !ok {
+			return "", ErrMissingInputs
+		}
+		if !tx.Signed[p.owners[output.Address]] {
+			return "", ErrIncompleteTransaction
+		}
+		balances[output.Asset] += output.Amount
+	}
+	for _, output := range tx.Outputs {
+		balances[output.Asset] -= output.Amount
+	}
+	for _, balance := range balances {
+		if balance != 0 {
+			return "", ErrInvalidTransaction
+		}
+	}
+
+	txID := tx.TxID()
+	for _, input := range tx.Inputs {
+		delete(p.unspents, input)
+		delete(p.locked, input)
+	}
+	for vout, output := range tx.Outputs {
+		if len(output.Address) == 0 {
+			continue // fee output
+		}
+		p.unspents[Outpoint{TxID: txID, Vout: vout}] = output
+	}
+	p.txs[txID] = tx
+	p.heights[txID] = 0
+
+	return txID, nil
+}
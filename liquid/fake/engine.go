@@ -0,0 +1,338 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+const (
+	DefaultFee = 500 // satoshi
+
+	fakeBlinder = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// Engine is a deterministic in-memory SwapBackend, with its own fake wallet
+type Engine struct {
+	Name  string
+	Chain *Chain
+	Fee   int64
+}
+
+func NewEngine(chain *Chain, name string) *Engine {
+	return &Engine{
+		Name:  name,
+		Chain: chain,
+		Fee:   DefaultFee,
+	}
+}
+
+// Fund create a new wallet unspent of amount satoshi
+func (p *Engine) Fund(asset string, amount int64) {
+	p.Chain.fund(p.Name, asset, amount)
+}
+
+func (p *Engine) NewAddress() common.ConfidentialAddress {
+	return common.ConfidentialAddress(p.Chain.newAddress(p.Name))
+}
+
+// Balance return wallet balance for asset in satoshi
+func (p *Engine) Balance(asset string) int64 {
+	var result int64
+	for _, utxo := range p.Chain.walletUnspents(p.Name, asset) {
+		result += utxo.Amount
+	}
+	return result
+}
+
+func (p *Engine) Propose(ctx context.Context, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) (common.Payload, error) {
+	proposerAsset := string(proposal.ProposerAsset)
+	proposerAmount := int64(proposal.ProposerAmount)
+
+	selected, total, err := p.selectCoins(proposerAsset, proposerAmount)
+	if err != nil {
+		return "", err
+	}
+
+	tx := Transaction{
+		Outputs: []Output{
+			{Address: string(address), Asset: string(proposal.ReceiverAsset), Amount: int64(proposal.ReceiverAmount)},
+		},
+	}
+	if change := total - proposerAmount; change > 0 {
+		tx.Outputs = append(tx.Outputs, Output{Address: p.Chain.newAddress(p.Name), Asset: proposerAsset, Amount: change})
+	}
+
+	var inputs []common.InputBlinder
+	for _, utxo := range selected {
+		tx.Inputs = append(tx.Inputs, utxo.Outpoint)
+		inputs = append(inputs, common.InputBlinder{
+			TxID:          utxo.TxID,
+			Vout:          utxo.Vout,
+			Asset:         utxo.Asset,
+			Amount:        common.Amount(utxo.Amount),
+			AssetBlinder:  fakeBlinder,
+			AmountBlinder: fakeBlinder,
+		})
+	}
+
+	return common.EncodePayload(&common.ProposalDocument{
+		ProtocolVersion: common.ProtocolVersion,
+		Tx:              tx.Encode(),
+		AssetP:          proposal.ProposerAsset,
+		AmountP:         proposal.ProposerAmount,
+		AssetR:          proposal.ReceiverAsset,
+		AmountR:         proposal.ReceiverAmount,
+		Inputs:          inputs,
+	}, common.PayloadBase64)
+}
+
+func (p *Engine) Info(ctx context.Context, payload common.Payload) (common.Payload, error) {
+	if proposal, err := payload.Proposal(); err == nil {
+		tx, err := DecodeTransaction(proposal.Tx)
+		if err != nil {
+			return "", err
+		}
+		return common.EncodePayload(&common.SwapInfo{
+			Stage:           common.PayloadStageProposal,
+			ProtocolVersion: proposal.ProtocolVersion,
+			ProposerAsset:   proposal.AssetP,
+			ProposerAmount:  proposal.AmountP,
+			ReceiverAsset:   proposal.AssetR,
+			ReceiverAmount:  proposal.AmountR,
+			Inputs:          len(tx.Inputs),
+			Outputs:         len(tx.Outputs),
+		}, common.PayloadJson)
+	}
+
+	accepted, err := payload.Accepted()
+	if err != nil {
+		return "", err
+	}
+	tx, err := DecodeTransaction(accepted.Tx)
+	if err != nil {
+		return "", err
+	}
+	return common.EncodePayload(&common.SwapInfo{
+		Stage:           common.PayloadStageAccepted,
+		ProtocolVersion: accepted.ProtocolVersion,
+		TxID:            tx.TxID(),
+		Fee:             accepted.Fee,
+		Locked:          true,
+		Inputs:          len(tx.Inputs),
+		Outputs:         len(tx.Outputs),
+	}, common.PayloadJson)
+}
+
+func (p *Engine) Accept(ctx context.Context, address common.ConfidentialAddress, payload common.Payload, feeRate common.Amount) (common.Payload, error) {
+	proposal, err := payload.Proposal()
+	if err != nil {
+		return "", err
+	}
+	proposerAsset := string(proposal.AssetP)
+	receiverAsset := string(proposal.AssetR)
+
+	tx, err := DecodeTransaction(proposal.Tx)
+	if err != nil {
+		return "", err
+	}
+
+	fundings := map[string]int64{
+		receiverAsset: int64(proposal.AmountR),
+	}
+	fundings[PolicyAsset] += p.Fee
+
+	tx.Outputs = append(tx.Outputs, Output{Address: string(address), Asset: proposerAsset, Amount: int64(proposal.AmountP)})
+	for _, asset := range []string{receiverAsset, PolicyAsset} {
+		amount, ok := fundings[asset]
+		if !ok {
+			continue
+		}
+		delete(fundings, asset)
+
+		selected, total, err := p.selectCoins(asset, amount)
+		if err != nil {
+			return "", err
+		}
+		for _, utxo := range selected {
+			tx.Inputs = append(tx.Inputs, utxo.Outpoint)
+		}
+		if change := total - amount; change > 0 {
+			tx.Outputs = append(tx.Outputs, Output{Address: p.Chain.newAddress(p.Name), Asset: asset, Amount: change})
+		}
+	}
+	tx.Outputs = append(tx.Outputs, Output{Asset: PolicyAsset, Amount: p.Fee})
+
+	tx.Signed = map[string]bool{p.Name: true}
+
+	return common.EncodePayload(&common.AcceptedDocument{
+		ProtocolVersion: common.ProtocolVersion,
+		Tx:              tx.Encode(),
+		Fee:             common.Amount(p.Fee),
+	}, common.PayloadBase64)
+}
+
+func (p *Engine) Finalize(ctx context.Context, payload common.Payload, terms common.SwapTerms, broadcast bool) (common.Payload, error) {
+	accepted, err := payload.Accepted()
+	if err != nil {
+		return "", err
+	}
+	tx, err := DecodeTransaction(accepted.Tx)
+	if err != nil {
+		return "", err
+	}
+
+	var walletInputs []common.Outpoint
+	for _, input := range tx.Inputs {
+		if owner, ok := p.Chain.owner(input); ok && owner == p.Name {
+			walletInputs = append(walletInputs, common.Outpoint{TxID: input.TxID, Vout: input.Vout})
+		}
+	}
+	received := make(map[common.AssetID]common.Amount)
+	for _, output := range tx.Outputs {
+		if p.Chain.addressOwner(output.Address) == p.Name {
+			received[common.AssetID(output.Asset)] += common.Amount(output.Amount)
+		}
+	}
+	if err := terms.Check(walletInputs, received); err != nil {
+		return "", err
+	}
+
+	// sign wallet inputs
+	if len(walletInputs) > 0 {
+		if tx.Signed == nil {
+			tx.Signed = make(map[string]bool)
+		}
+		tx.Signed[p.Name] = true
+	}
+
+	txID := tx.TxID()
+	if broadcast {
+		txID, err = p.Chain.broadcast(tx)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return common.EncodePayload(&common.FinalizedDocument{
+		TxID: txID,
+		Tx:   tx.Encode(),
+	}, common.PayloadJson)
+}
+
+func (p *Engine) Broadcast(ctx context.Context, txHex string) (string, error) {
+	tx, err := DecodeTransaction(txHex)
+	if err != nil {
+		return "", err
+	}
+	return p.Chain.broadcast(tx)
+}
+
+func (p *Engine) TxID(ctx context.Context, txHex string) (string, error) {
+	tx, err := DecodeTransaction(txHex)
+	if err != nil {
+		return "", err
+	}
+	return tx.TxID(), nil
+}
+
+func (p *Engine) Cancel(ctx context.Context, payload common.Payload, feeRate common.Amount) (common.Payload, error) {
+	proposal, err := payload.Proposal()
+	if err != nil {
+		return "", err
+	}
+	proposerAsset := string(proposal.AssetP)
+
+	var tx Transaction
+	var total int64
+	for _, input := range proposal.Inputs {
+		outpoint := Outpoint{TxID: input.TxID, Vout: input.Vout}
+		if owner, ok := p.Chain.owner(outpoint); !ok || owner != p.Name {
+			return "", common.ErrInvalidSwapState
+		}
+		tx.Inputs = append(tx.Inputs, outpoint)
+		total += int64(input.Amount)
+	}
+
+	if proposerAsset == PolicyAsset {
+		if total <= p.Fee {
+			return "", ErrInsufficientFunds
+		}
+		tx.Outputs = append(tx.Outputs, Output{Address: p.Chain.newAddress(p.Name), Asset: proposerAsset, Amount: total - p.Fee})
+	} else {
+		tx.Outputs = append(tx.Outputs, Output{Address: p.Chain.newAddress(p.Name), Asset: proposerAsset, Amount: total})
+
+		selected, fundings, err := p.selectCoins(PolicyAsset, p.Fee)
+		if err != nil {
+			return "", err
+		}
+		for _, utxo := range selected {
+			tx.Inputs = append(tx.Inputs, utxo.Outpoint)
+		}
+		if change := fundings - p.Fee; change > 0 {
+			tx.Outputs = append(tx.Outputs, Output{Address: p.Chain.newAddress(p.Name), Asset: PolicyAsset, Amount: change})
+		}
+	}
+	tx.Outputs = append(tx.Outputs, Output{Asset: PolicyAsset, Amount: p.Fee})
+	tx.Signed = map[string]bool{p.Name: true}
+
+	txID, err := p.Chain.broadcast(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return common.EncodePayload(&common.CancelledDocument{
+		CancelTxID: txID,
+		Tx:         tx.Encode(),
+	}, common.PayloadJson)
+}
+
+func (p *Engine) LockUnspents(ctx context.Context, outpoints []common.Outpoint) error {
+	p.Chain.lock(chainOutpoints(outpoints), true)
+	return nil
+}
+
+func (p *Engine) UnlockUnspents(ctx context.Context, outpoints []common.Outpoint) error {
+	p.Chain.lock(chainOutpoints(outpoints), false)
+	return nil
+}
+
+func (p *Engine) Balances(ctx context.Context) (map[common.AssetID]common.Amount, error) {
+	result := make(map[common.AssetID]common.Amount)
+	for _, utxo := range p.Chain.walletUnspents(p.Name, "") {
+		result[common.AssetID(utxo.Asset)] += common.Amount(utxo.Amount)
+	}
+	return result, nil
+}
+
+func (p *Engine) Confirmations(ctx context.Context, txID string) (int, error) {
+	return p.Chain.confirmations(txID)
+}
+
+func (p *Engine) selectCoins(asset string, amount int64) ([]unspent, int64, error) {
+	var result []unspent
+	var total int64
+	for _, utxo := range p.Chain.walletUnspents(p.Name, asset) {
+		if total >= amount {
+			break
+		}
+		result = append(result, utxo)
+		total += utxo.Amount
+	}
+	if total < amount || len(result) == 0 {
+		return nil, 0, ErrInsufficientFunds
+	}
+	return result, total, nil
+}
+
+func chainOutpoints(outpoints []common.Outpoint) []Outpoint {
+	var result []Outpoint
+	for _, outpoint := range outpoints {
+		result = append(result, Outpoint{TxID: outpoint.TxID, Vout: outpoint.Vout})
+	}
+	return result
+}
@@ -6,14 +6,13 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/condensat/bank-core"
 	"github.com/condensat/bank-core/logger"
-	"github.com/condensat/bank-core/utils/shellexec"
 
 	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
 
 	"github.com/condensat/bank-core/cache"
 	"github.com/condensat/bank-core/messaging"
@@ -21,11 +20,26 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func AcceptSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, payload common.Payload, feeRate float64) (common.SwapProposal, error) {
+func AcceptSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, payload common.Payload, feeRate common.Amount, expiry time.Time) (common.SwapProposal, error) {
 	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.AcceptSwapProposal")
 
 	log = log.WithField("SwapID", swapID)
 
+	// same deadline for queue wait, wallet lock and backend calls
+	ctxOperation, cancel := operationContext(ctx, common.SwapAcceptProposalSubject)
+	defer cancel()
+
+	network := common.NetworkFromContext(ctx)
+	if err := address.Validate(network); err != nil {
+		log.WithError(err).
+			WithField("Address", address).
+			Error("Invalid Address")
+		return common.SwapProposal{}, common.ErrInvalidAddress
+	}
+	if feeRate == 0 {
+		feeRate = network.Params().DefaultFeeRate
+	}
+
 	if !payload.Valid() {
 		log.WithError(common.ErrInvalidPayload).
 			WithField("Payload", payload).
@@ -38,25 +52,63 @@ func AcceptSwapProposal(ctx context.Context, swapID uint64, address common.Confi
 		SwapID:    swapID,
 	}
 
-	ShellExecLock.Lock()
-	defer ShellExecLock.Unlock()
+	wallet, backend, err := walletBackend(ctx)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", wallet).
+			Error("Wallet backend not found")
+		return common.SwapProposal{}, err
+	}
+	result.Wallet = wallet
+
+	lock, err := queueWallet(ctxOperation, wallet, common.SwapAcceptProposalSubject)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to lock wallet")
+		return common.SwapProposal{}, err
+	}
+	defer lock.Unlock()
 
-	out, err := shellexec.Execute(ctx, LiquidSwapAccept(address, payload, feeRate))
-	if len(out.Stdout) == 0 && err == nil {
-		err = errors.New("No Output")
+	// repeated request return the previous result
+	fingerprint := requestFingerprint(wallet, address, payload, feeRate, expiry)
+	previous, ok, err := previousResult(ctx, swapID, state.OperationAccept, fingerprint)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap operation already done for another request")
+		return common.SwapProposal{}, err
 	}
+	if ok {
+		log.Debug("Swap operation already done")
+		return previous, nil
+	}
+
+	// requested expiry can only shorten the recorded proposal expiry
+	expiry = common.EarliestExpiry(swapExpiry(ctx, swapID), expiry)
+	err = checkExpiry(ctx, swapID, expiry)
 	if err != nil {
 		log.WithError(err).
-			WithFields(logrus.Fields{
-				"Stdout": out.Stdout,
-				"Stderr": out.Stderr,
-				"Code":   out.Code,
-			}).
-			Error("out")
+			WithField("Expiry", expiry).
+			Error("Proposal expired")
+		return common.SwapProposal{}, err
+	}
+	result.Expiry = expiry
+
+	err = checkSwapTransition(ctx, swapID, state.SwapStateAccepted)
+	if err != nil {
+		log.WithError(err).
+			Error("Invalid swap state")
+		return common.SwapProposal{}, err
+	}
+
+	out, err := backend.Accept(ctxOperation, address, payload, feeRate)
+	err = backendError(ctxOperation, err)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap Backend failed")
 		return result, err
 	}
 
-	result.Payload = common.Payload(out.Stdout)
+	result.Payload = out
 
 	if !result.Payload.Valid() {
 		log.WithError(common.ErrInvalidPayload).
@@ -65,6 +117,28 @@ func AcceptSwapProposal(ctx context.Context, swapID uint64, address common.Confi
 		return common.SwapProposal{}, common.ErrInvalidPayload
 	}
 
+	err = recordSwapTransitions(ctx, swapID, func(record *state.SwapRecord) {
+		record.Wallet = wallet
+		if record.ExpiresAt.IsZero() {
+			record.ExpiresAt = expiry
+		}
+		if proposal, err := payload.Proposal(); err == nil {
+			record.Proposal = common.ProposalInfo{
+				ProposerAsset:  proposal.AssetP,
+				ProposerAmount: proposal.AmountP,
+				ReceiverAsset:  proposal.AssetR,
+				ReceiverAmount: proposal.AmountR,
+			}
+		}
+	}, state.SwapStateAccepted)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to record swap state")
+		return common.SwapProposal{}, err
+	}
+
+	saveResult(ctx, swapID, state.OperationAccept, fingerprint, result)
+
 	log.WithField("Result", result).
 		Debug("Accept Swap Proposal")
 
@@ -77,21 +151,42 @@ func OnAcceptSwapProposal(ctx context.Context, subject string, message *bank.Mes
 		"Subject": subject,
 	})
 
-	var request common.SwapProposal
+	var request common.AcceptProposalRequest
 	return messaging.HandleRequest(ctx, message, &request,
 		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
 			log = log.WithFields(logrus.Fields{
 				"SwapID": request.SwapID,
 			})
 
-			response, err := AcceptSwapProposal(ctx, request.SwapID, request.Address, request.Payload, request.FeeRate)
+			err := common.CheckSchemaVersion(request.Version)
+			if err == nil {
+				err = request.Validate(common.NetworkFromContext(ctx))
+			}
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Invalid request")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result := errorResponse(request.SwapID, request.Wallet, err)
+				response := result.AcceptProposalResponse()
+				return &response, nil
+			}
+
+			ctx = common.SwapWalletContext(ctx, request.Wallet)
+			result, err := AcceptSwapProposal(ctx, request.SwapID, request.Address, request.Payload, request.FeeRate, request.Expiry)
 			if err != nil {
 				log.WithError(err).
 					Errorf("Failed to AcceptSwapProposal")
-				return nil, cache.ErrInternalError
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result = errorResponse(request.SwapID, request.Wallet, err)
 			}
 
 			// create & return response
+			response := result.AcceptProposalResponse()
 			return &response, nil
 		})
 }
@@ -0,0 +1,109 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+const (
+	SwapBackendKey = "Key.SwapBackendKey"
+	SwapWalletsKey = "Key.SwapWalletsKey"
+)
+
+func SwapBackendContext(ctx context.Context, backend common.SwapBackend) context.Context {
+	return context.WithValue(ctx, SwapBackendKey, backend)
+}
+
+func SwapBackendFromContext(ctx context.Context) common.SwapBackend {
+	switch backend := ctx.Value(SwapBackendKey).(type) {
+	case common.SwapBackend:
+		return backend
+
+	default:
+		return nil
+	}
+}
+
+// WalletRegistry route swap requests to the wallet backend
+type WalletRegistry struct {
+	defaultWallet string
+	backends      map[string]common.SwapBackend
+}
+
+func NewWalletRegistry(defaultWallet string) *WalletRegistry {
+	if len(defaultWallet) == 0 {
+		defaultWallet = common.DefaultWallet
+	}
+	return &WalletRegistry{
+		defaultWallet: defaultWallet,
+		backends:      make(map[string]common.SwapBackend),
+	}
+}
+
+// Register must be called before the registry is used by handlers
+func (p *WalletRegistry) Register(wallet string, backend common.SwapBackend) {
+	p.backends[wallet] = backend
+}
+
+// Backend return the default wallet backend if wallet is empty
+func (p *WalletRegistry) Backend(wallet string) (string, common.SwapBackend, error) {
+	if len(wallet) == 0 {
+		wallet = p.defaultWallet
+	}
+	backend, ok := p.backends[wallet]
+	if !ok {
+		return wallet, nil, common.ErrWalletNotFound
+	}
+	return wallet, backend, nil
+}
+
+func (p *WalletRegistry) Wallets() []string {
+	var result []string
+	for wallet := range p.backends {
+		result = append(result, wallet)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func SwapWalletsContext(ctx context.Context, wallets *WalletRegistry) context.Context {
+	return context.WithValue(ctx, SwapWalletsKey, wallets)
+}
+
+func SwapWalletsFromContext(ctx context.Context) *WalletRegistry {
+	switch wallets := ctx.Value(SwapWalletsKey).(type) {
+	case *WalletRegistry:
+		return wallets
+
+	default:
+		return nil
+	}
+}
+
+// walletBackend return the backend for the wallet requested in context.
+// Single SwapBackend from context is used as default wallet without WalletRegistry.
+func walletBackend(ctx context.Context) (string, common.SwapBackend, error) {
+	wallet := common.SwapWalletFromContext(ctx)
+
+	if wallets := SwapWalletsFromContext(ctx); wallets != nil {
+		return wallets.Backend(wallet)
+	}
+
+	if len(wallet) == 0 {
+		wallet = common.DefaultWallet
+	}
+	if wallet != common.DefaultWallet {
+		return wallet, nil, common.ErrWalletNotFound
+	}
+	backend := SwapBackendFromContext(ctx)
+	if backend == nil {
+		return wallet, nil, common.ErrBackendNotFound
+	}
+	return wallet, backend, nil
+}
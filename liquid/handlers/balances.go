@@ -0,0 +1,110 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+
+	"github.com/condensat/bank-core/messaging"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SwapBalances return spendable and reserved wallet amounts by asset
+func SwapBalances(ctx context.Context) (common.SwapBalances, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.SwapBalances")
+
+	// operation deadline for backend calls
+	ctxOperation, cancel := operationContext(ctx, common.SwapBalancesSubject)
+	defer cancel()
+
+	result := common.SwapBalances{
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	wallet, backend, err := walletBackend(ctx)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", wallet).
+			Error("Wallet backend not found")
+		return common.SwapBalances{}, err
+	}
+	result.Wallet = wallet
+	log = log.WithField("Wallet", wallet)
+
+	spendable, err := backend.Balances(ctxOperation)
+	err = backendError(ctxOperation, err)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap Backend failed")
+		return common.SwapBalances{}, err
+	}
+
+	var reservations []state.Reservation
+	if store := state.SwapStoreFromContext(ctx); store != nil {
+		reservations, err = store.Reservations(ctx, wallet)
+		if err != nil {
+			log.WithError(err).
+				Error("Failed to get reservations")
+			return common.SwapBalances{}, err
+		}
+	}
+
+	result.Balances = common.NewAssetBalances(spendable, state.ReservedAmounts(reservations))
+
+	log.WithField("Result", result).
+		Debug("Swap Balances")
+
+	return result, nil
+}
+
+func OnSwapBalances(ctx context.Context, subject string, message *bank.Message) (*bank.Message, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.OnSwapBalances")
+	log = log.WithFields(logrus.Fields{
+		"Subject": subject,
+	})
+
+	var request common.SwapBalances
+	return messaging.HandleRequest(ctx, message, &request,
+		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
+			log = log.WithFields(logrus.Fields{
+				"Wallet": request.Wallet,
+			})
+
+			err := common.CheckSchemaVersion(request.Version)
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Unsupported request version")
+				return &common.SwapBalances{
+					Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+					Wallet:    request.Wallet,
+					Error:     common.NewSwapError(err),
+				}, nil
+			}
+
+			ctx = common.SwapWalletContext(ctx, request.Wallet)
+			response, err := SwapBalances(ctx)
+			if err != nil {
+				log.WithError(err).
+					Errorf("Failed to SwapBalances")
+				return &common.SwapBalances{
+					Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+					Wallet:    request.Wallet,
+					Error:     common.NewSwapError(err),
+				}, nil
+			}
+
+			// create & return response
+			return &response, nil
+		})
+}
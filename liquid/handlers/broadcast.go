@@ -0,0 +1,193 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+
+	"github.com/condensat/bank-core/cache"
+	"github.com/condensat/bank-core/messaging"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BroadcastSwapTransaction send a transaction signed by FinalizeSwapProposal without broadcast
+func BroadcastSwapTransaction(ctx context.Context, swapID uint64, payload common.Payload) (common.SwapProposal, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.BroadcastSwapTransaction")
+
+	log = log.WithField("SwapID", swapID)
+
+	// same deadline for queue wait, wallet lock and backend calls
+	ctxOperation, cancel := operationContext(ctx, common.SwapBroadcastSubject)
+	defer cancel()
+
+	finalized, err := payload.Finalized()
+	if err != nil || len(finalized.Tx) == 0 {
+		log.WithError(common.ErrInvalidPayload).
+			WithField("Payload", payload).
+			Error("Invalid Payload")
+		return common.SwapProposal{}, common.ErrInvalidPayload
+	}
+
+	result := common.SwapProposal{
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+		SwapID:    swapID,
+	}
+
+	wallet, backend, err := walletBackend(ctx)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", wallet).
+			Error("Wallet backend not found")
+		return common.SwapProposal{}, err
+	}
+	result.Wallet = wallet
+
+	lock, err := queueWallet(ctxOperation, wallet, common.SwapBroadcastSubject)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to lock wallet")
+		return common.SwapProposal{}, err
+	}
+	defer lock.Unlock()
+
+	// repeated request return the previous result
+	fingerprint := requestFingerprint(wallet, payload)
+	previous, ok, err := previousResult(ctx, swapID, state.OperationBroadcast, fingerprint)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap operation already done for another request")
+		return common.SwapProposal{}, err
+	}
+	if ok {
+		log.Debug("Swap operation already done")
+		return previous, nil
+	}
+
+	err = checkSwapTransition(ctx, swapID, state.SwapStateBroadcast)
+	if err != nil {
+		log.WithError(err).
+			Error("Invalid swap state")
+		return common.SwapProposal{}, err
+	}
+
+	// only the transaction signed by FinalizeSwapProposal can be sent
+	txID, err := backend.TxID(ctxOperation, finalized.Tx)
+	err = backendError(ctxOperation, err)
+	if err == nil {
+		err = checkFinalizedTxID(ctx, swapID, txID)
+	}
+	if err == nil {
+		var sent string
+		sent, err = backend.Broadcast(ctxOperation, finalized.Tx)
+		err = backendError(ctxOperation, err)
+		// swap transaction is tracked by its id, Broadcast state requires it
+		if err == nil && sent != txID {
+			err = common.ErrInvalidPayload
+		}
+	}
+	if err != nil {
+		log.WithError(err).
+			WithField("TxID", txID).
+			Error("Failed to broadcast swap transaction")
+		return result, err
+	}
+
+	result.Payload, err = common.EncodePayload(&common.FinalizedDocument{
+		TxID: txID,
+		Tx:   finalized.Tx,
+	}, common.PayloadJson)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to encode payload")
+		return common.SwapProposal{}, common.ErrInvalidPayload
+	}
+
+	err = recordSwapTransitions(ctx, swapID, nil, state.SwapStateBroadcast)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to record swap state")
+		return common.SwapProposal{}, err
+	}
+
+	// proposal unspents are spent by the swap transaction
+	releaseUnspents(ctx, swapID)
+
+	saveResult(ctx, swapID, state.OperationBroadcast, fingerprint, result)
+
+	log.WithField("TxID", txID).
+		Debug("Broadcast Swap Transaction")
+
+	return result, nil
+}
+
+// checkFinalizedTxID return ErrInvalidPayload if txID is not the transaction recorded by FinalizeSwapProposal
+func checkFinalizedTxID(ctx context.Context, swapID uint64, txID string) error {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return common.ErrInvalidSwapState
+	}
+	record, err := store.Get(ctx, swapID)
+	if err != nil || record.State != state.SwapStateFinalized {
+		return common.ErrInvalidSwapState
+	}
+	if len(record.TxID) == 0 || record.TxID != txID {
+		return common.ErrInvalidPayload
+	}
+	return nil
+}
+
+func OnBroadcastSwapTransaction(ctx context.Context, subject string, message *bank.Message) (*bank.Message, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.OnBroadcastSwapTransaction")
+	log = log.WithFields(logrus.Fields{
+		"Subject": subject,
+	})
+
+	var request common.BroadcastRequest
+	return messaging.HandleRequest(ctx, message, &request,
+		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
+			log = log.WithFields(logrus.Fields{
+				"SwapID": request.SwapID,
+			})
+
+			err := common.CheckSchemaVersion(request.Version)
+			if err == nil {
+				err = request.Validate(common.NetworkFromContext(ctx))
+			}
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Invalid request")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result := errorResponse(request.SwapID, request.Wallet, err)
+				response := result.BroadcastResponse()
+				return &response, nil
+			}
+
+			ctx = common.SwapWalletContext(ctx, request.Wallet)
+			result, err := BroadcastSwapTransaction(ctx, request.SwapID, request.Payload)
+			if err != nil {
+				log.WithError(err).
+					Errorf("Failed to BroadcastSwapTransaction")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result = errorResponse(request.SwapID, request.Wallet, err)
+			}
+
+			// create & return response
+			response := result.BroadcastResponse()
+			return &response, nil
+		})
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+
+	"github.com/condensat/bank-core/cache"
+	"github.com/condensat/bank-core/messaging"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CancelSwapProposal double spend the proposal inputs back to the proposer wallet.
+// The swap can not be finalized once the cancel transaction is sent.
+func CancelSwapProposal(ctx context.Context, swapID uint64, payload common.Payload, feeRate common.Amount) (common.SwapProposal, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.CancelSwapProposal")
+
+	log = log.WithField("SwapID", swapID)
+
+	// same deadline for queue wait, wallet lock and backend calls
+	ctxOperation, cancel := operationContext(ctx, common.SwapCancelProposalSubject)
+	defer cancel()
+
+	if !payload.Valid() {
+		log.WithError(common.ErrInvalidPayload).
+			WithField("Payload", payload).
+			Error("Invalid Payload")
+		return common.SwapProposal{}, common.ErrInvalidPayload
+	}
+	if feeRate == 0 {
+		feeRate = common.NetworkFromContext(ctx).Params().DefaultFeeRate
+	}
+
+	result := common.SwapProposal{
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+		SwapID:    swapID,
+	}
+
+	wallet, backend, err := walletBackend(ctx)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", wallet).
+			Error("Wallet backend not found")
+		return common.SwapProposal{}, err
+	}
+	result.Wallet = wallet
+
+	lock, err := queueWallet(ctxOperation, wallet, common.SwapCancelProposalSubject)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to lock wallet")
+		return common.SwapProposal{}, err
+	}
+	defer lock.Unlock()
+
+	// repeated request return the previous result
+	fingerprint := requestFingerprint(wallet, payload, feeRate)
+	previous, ok, err := previousResult(ctx, swapID, state.OperationCancel, fingerprint)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap operation already done for another request")
+		return common.SwapProposal{}, err
+	}
+	if ok {
+		log.Debug("Swap operation already done")
+		return previous, nil
+	}
+
+	// only proposer inputs can be spent back
+	if payload.Stage() != common.PayloadStageProposal {
+		log.WithError(state.ErrInvalidTransition).
+			Error("Not a swap proposal")
+		return common.SwapProposal{}, state.ErrInvalidTransition
+	}
+	err = checkSwapTransition(ctx, swapID, state.SwapStateCancelled)
+	if err != nil {
+		log.WithError(err).
+			Error("Invalid swap state")
+		return common.SwapProposal{}, err
+	}
+
+	// reserved unspents must be unlocked to be spent back
+	reservation, reserved := releaseUnspents(ctx, swapID)
+
+	out, err := backend.Cancel(ctxOperation, payload, feeRate)
+	err = backendError(ctxOperation, err)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap Backend failed")
+		if reserved {
+			restoreUnspents(ctx, reservation)
+		}
+		return result, err
+	}
+
+	result.Payload = out
+
+	cancelled, err := result.Payload.Cancelled()
+	if err != nil {
+		log.WithError(common.ErrInvalidPayload).
+			WithField("Payload", result.Payload).
+			Error("Invalid Payload")
+		return common.SwapProposal{}, common.ErrInvalidPayload
+	}
+
+	err = recordSwapTransitions(ctx, swapID, func(record *state.SwapRecord) {
+		record.TxID = cancelled.CancelTxID
+	}, state.SwapStateCancelled)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to record swap state")
+		return common.SwapProposal{}, err
+	}
+
+	saveResult(ctx, swapID, state.OperationCancel, fingerprint, result)
+
+	log.WithFields(logrus.Fields{
+		"Result":     result,
+		"CancelTxID": cancelled.CancelTxID,
+	}).Debug("Cancel Swap Proposal")
+
+	return result, nil
+}
+
+func OnCancelSwapProposal(ctx context.Context, subject string, message *bank.Message) (*bank.Message, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.OnCancelSwapProposal")
+	log = log.WithFields(logrus.Fields{
+		"Subject": subject,
+	})
+
+	var request common.CancelProposalRequest
+	return messaging.HandleRequest(ctx, message, &request,
+		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
+			log = log.WithFields(logrus.Fields{
+				"SwapID": request.SwapID,
+			})
+
+			err := common.CheckSchemaVersion(request.Version)
+			if err == nil {
+				err = request.Validate(common.NetworkFromContext(ctx))
+			}
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Invalid request")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result := errorResponse(request.SwapID, request.Wallet, err)
+				response := result.CancelProposalResponse()
+				return &response, nil
+			}
+
+			ctx = common.SwapWalletContext(ctx, request.Wallet)
+			result, err := CancelSwapProposal(ctx, request.SwapID, request.Payload, request.FeeRate)
+			if err != nil {
+				log.WithError(err).
+					Errorf("Failed to CancelSwapProposal")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result = errorResponse(request.SwapID, request.Wallet, err)
+			}
+
+			// create & return response
+			response := result.CancelProposalResponse()
+			return &response, nil
+		})
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/condensat/bank-core/logger"
+	"github.com/condensat/bank-core/utils/shellexec"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/native"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CliBackend is the SwapBackend adapter running liquidswap-cli
+// Operations not supported by liquidswap-cli use the native backend with the same elements configuration
+type CliBackend struct {
+	ElementsConf string
+	elements     *native.Backend
+}
+
+func NewCliBackend(elementsConf string) (*CliBackend, error) {
+	elements, err := native.NewBackend(elementsConf)
+	if err != nil {
+		return nil, err
+	}
+	return &CliBackend{
+		ElementsConf: elementsConf,
+		elements:     elements,
+	}, nil
+}
+
+func (p *CliBackend) Propose(ctx context.Context, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) (common.Payload, error) {
+	return p.execute(ctx, LiquidSwapPropose(p.ElementsConf, address, proposal, feeRate))
+}
+
+func (p *CliBackend) Info(ctx context.Context, payload common.Payload) (common.Payload, error) {
+	return p.execute(ctx, LiquidSwapInfo(p.ElementsConf, payload))
+}
+
+func (p *CliBackend) Accept(ctx context.Context, address common.ConfidentialAddress, payload common.Payload, feeRate common.Amount) (common.Payload, error) {
+	return p.execute(ctx, LiquidSwapAccept(p.ElementsConf, address, payload, feeRate))
+}
+
+func (p *CliBackend) Finalize(ctx context.Context, payload common.Payload, terms common.SwapTerms, broadcast bool) (common.Payload, error) {
+	accepted, err := payload.Accepted()
+	if err != nil {
+		return "", err
+	}
+	// liquidswap-cli sign all wallet inputs, check before signing
+	err = p.elements.CheckTerms(ctx, accepted.Tx, terms)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := p.execute(ctx, LiquidSwapFinalize(p.ElementsConf, payload, broadcast))
+	if err != nil {
+		return "", err
+	}
+
+	var finalized common.FinalizedDocument
+	if broadcast {
+		finalized, err = parseBroadcastOutput(out)
+	} else {
+		finalized, err = parseFinalizeOutput(out)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(finalized.TxID) == 0 {
+		finalized.TxID, err = p.TxID(ctx, finalized.Tx)
+		if err != nil {
+			return "", err
+		}
+	}
+	return common.EncodePayload(&finalized, common.PayloadJson)
+}
+
+// parseFinalizeOutput read liquidswap-cli finalize output without --send,
+// the signed transaction hex or a json FinalizedDocument
+func parseFinalizeOutput(out common.Payload) (common.FinalizedDocument, error) {
+	txHex := strings.TrimSpace(string(out))
+	if _, err := hex.DecodeString(txHex); err == nil && len(txHex) > 0 {
+		return common.FinalizedDocument{
+			Tx: txHex,
+		}, nil
+	}
+
+	var result common.FinalizedDocument
+	if err := out.Decode(&result); err != nil || len(result.Tx) == 0 {
+		return common.FinalizedDocument{}, common.ErrInvalidPayload
+	}
+	return result, nil
+}
+
+// parseBroadcastOutput read liquidswap-cli finalize output with --send,
+// the sent transaction id or a json FinalizedDocument
+func parseBroadcastOutput(out common.Payload) (common.FinalizedDocument, error) {
+	txID := strings.TrimSpace(string(out))
+	if _, err := hex.DecodeString(txID); err == nil && len(txID) == common.TxIDLength {
+		return common.FinalizedDocument{
+			TxID: txID,
+		}, nil
+	}
+
+	var result common.FinalizedDocument
+	if err := out.Decode(&result); err != nil || len(result.TxID) != common.TxIDLength {
+		return common.FinalizedDocument{}, common.ErrInvalidPayload
+	}
+	return result, nil
+}
+
+func (p *CliBackend) Broadcast(ctx context.Context, tx string) (string, error) {
+	return p.elements.Broadcast(ctx, tx)
+}
+
+func (p *CliBackend) TxID(ctx context.Context, tx string) (string, error) {
+	return p.elements.TxID(ctx, tx)
+}
+
+// Cancel is not supported by liquidswap-cli
+func (p *CliBackend) Cancel(ctx context.Context, payload common.Payload, feeRate common.Amount) (common.Payload, error) {
+	return p.elements.Cancel(ctx, payload, feeRate)
+}
+
+// LockUnspents use elementsd lockunspent, liquidswap-cli coin selection skip locked unspents
+func (p *CliBackend) LockUnspents(ctx context.Context, outpoints []common.Outpoint) error {
+	return p.elements.LockUnspents(ctx, outpoints)
+}
+
+func (p *CliBackend) UnlockUnspents(ctx context.Context, outpoints []common.Outpoint) error {
+	return p.elements.UnlockUnspents(ctx, outpoints)
+}
+
+func (p *CliBackend) Balances(ctx context.Context) (map[common.AssetID]common.Amount, error) {
+	return p.elements.Balances(ctx)
+}
+
+func (p *CliBackend) Confirmations(ctx context.Context, txID string) (int, error) {
+	return p.elements.Confirmations(ctx, txID)
+}
+
+func (p *CliBackend) execute(ctx context.Context, options shellexec.Options) (common.Payload, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.CliBackend")
+
+	// subprocess is killed on the operation deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		options.Timeout = time.Until(deadline)
+	}
+
+	out, err := shellexec.Execute(ctx, options)
+	if len(out.Stdout) == 0 && err == nil {
+		err = common.ErrNoOutput
+	}
+	if err != nil {
+		err = ClassifyCliError(out, err)
+
+		fields := logrus.Fields{
+			"Stdout": out.Stdout,
+			"Stderr": out.Stderr,
+			"Code":   out.Code,
+		}
+		if cliError, ok := err.(*CliError); ok {
+			fields["Kind"] = cliError.Kind
+			fields["Details"] = cliError.Details
+		}
+		log.WithError(err).
+			WithFields(fields).
+			Error("out")
+		return "", err
+	}
+
+	return common.Payload(out.Stdout), nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func TestParseFinalizeOutput(t *testing.T) {
+	t.Parallel()
+
+	const txID = "5f3c1f0c9d4ea6a1c8a3b0e2d7f6e5d4c3b2a19088776655443322110fedcba9"
+
+	tests := []struct {
+		fixture  string
+		wantTxID string
+		wantErr  error
+	}{
+		{"finalize.txt", "", nil},
+		{"finalize_json.txt", txID, nil},
+		{"finalize_invalid.txt", "", common.ErrInvalidPayload},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.fixture, func(t *testing.T) {
+			t.Parallel()
+
+			stdout, err := ioutil.ReadFile(filepath.Join("testdata", "stdout", tt.fixture))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			got, err := parseFinalizeOutput(common.Payload(stdout))
+			if err != tt.wantErr {
+				t.Fatalf("parseFinalizeOutput() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.TxID != tt.wantTxID {
+				t.Errorf("parseFinalizeOutput() TxID = %v, want %v", got.TxID, tt.wantTxID)
+			}
+			if len(got.Tx) == 0 || strings.TrimSpace(got.Tx) != got.Tx {
+				t.Errorf("parseFinalizeOutput() invalid Tx %q", got.Tx)
+			}
+		})
+	}
+}
+
+func TestParseBroadcastOutput(t *testing.T) {
+	t.Parallel()
+
+	const txID = "5f3c1f0c9d4ea6a1c8a3b0e2d7f6e5d4c3b2a19088776655443322110fedcba9"
+
+	tests := []struct {
+		fixture  string
+		wantTxID string
+		wantErr  error
+	}{
+		{"finalize_send.txt", txID, nil},
+		{"finalize_json.txt", txID, nil},
+		{"finalize.txt", "", common.ErrInvalidPayload},
+		{"finalize_invalid.txt", "", common.ErrInvalidPayload},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.fixture, func(t *testing.T) {
+			t.Parallel()
+
+			stdout, err := ioutil.ReadFile(filepath.Join("testdata", "stdout", tt.fixture))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			got, err := parseBroadcastOutput(common.Payload(stdout))
+			if err != tt.wantErr {
+				t.Fatalf("parseBroadcastOutput() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.TxID != tt.wantTxID {
+				t.Errorf("parseBroadcastOutput() TxID = %v, want %v", got.TxID, tt.wantTxID)
+			}
+		})
+	}
+}
+
+func TestNewCliBackend(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewCliBackend(filepath.Join("testdata", "missing.conf")); err == nil {
+		t.Errorf("NewCliBackend() missing conf must fail")
+	}
+}
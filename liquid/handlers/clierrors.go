@@ -0,0 +1,161 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/condensat/bank-core/utils/shellexec"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+type CliErrorKind string
+
+const (
+	CliErrorUnknown             = CliErrorKind("Unknown")
+	CliErrorTimeout             = CliErrorKind("Timeout")
+	CliErrorInsufficientBalance = CliErrorKind("InsufficientBalance")
+	CliErrorWalletLocked        = CliErrorKind("WalletLocked")
+	CliErrorConnectionRefused   = CliErrorKind("ConnectionRefused")
+	CliErrorInvalidProposal     = CliErrorKind("InvalidProposal")
+	CliErrorAssetMismatch       = CliErrorKind("AssetMismatch")
+	CliErrorFeeTooLow           = CliErrorKind("FeeTooLow")
+)
+
+// CliError is a classified liquidswap-cli failure
+type CliError struct {
+	Kind    CliErrorKind
+	Message string            // last stderr line
+	Details map[string]string // values extracted from stderr
+	Code    shellexec.ErrorCode
+
+	err error
+}
+
+func (p *CliError) Error() string {
+	return fmt.Sprintf("%s: %s", p.Kind, p.Message)
+}
+
+// Unwrap return the swap error for Kind
+func (p *CliError) Unwrap() error {
+	return p.err
+}
+
+type cliErrorPattern struct {
+	Kind    CliErrorKind
+	Err     error
+	Match   *regexp.Regexp
+	Details *regexp.Regexp // named groups are returned in details
+}
+
+// cliErrorPatterns are checked in order, first match is used
+var cliErrorPatterns = []cliErrorPattern{
+	{
+		Kind:    CliErrorConnectionRefused,
+		Err:     common.ErrBackendUnavailable,
+		Match:   regexp.MustCompile(`(?i)connection refused|could not connect|'code': -28\b`),
+		Details: regexp.MustCompile(`\[Errno (?P<errno>\d+)\]`),
+	},
+	{
+		Kind:  CliErrorWalletLocked,
+		Err:   common.ErrWalletLocked,
+		Match: regexp.MustCompile(`(?i)walletpassphrase|wallet is locked|'code': -13\b`),
+	},
+	{
+		Kind:    CliErrorInsufficientBalance,
+		Err:     common.ErrInsufficientFunds,
+		Match:   regexp.MustCompile(`(?i)insufficient (funds|balance)|'code': -6\b`),
+		Details: regexp.MustCompile(`(?i)asset (?P<asset>[0-9a-f]{64}): needed (?P<needed>[0-9.]+), available (?P<available>[0-9.]+)`),
+	},
+	{
+		Kind:    CliErrorFeeTooLow,
+		Err:     common.ErrFeeTooLow,
+		Match:   regexp.MustCompile(`(?i)min relay fee not met|fee (rate )?too low`),
+		Details: regexp.MustCompile(`(?P<fee>\d+) < (?P<required>\d+)`),
+	},
+	{
+		Kind:    CliErrorAssetMismatch,
+		Err:     common.ErrAssetMismatch,
+		Match:   regexp.MustCompile(`(?i)asset mismatch|unexpected asset`),
+		Details: regexp.MustCompile(`(?i)expected (?P<expected>[0-9a-f]{64}), found (?P<found>[0-9a-f]{64})`),
+	},
+	{
+		Kind:    CliErrorInvalidProposal,
+		Err:     common.ErrInvalidPayload,
+		Match:   regexp.MustCompile(`(?i)invalid (proposal|payload)|UnexpectedValueError`),
+		Details: regexp.MustCompile(`(?i)field "(?P<field>\w+)"`),
+	},
+}
+
+var rpcCodePattern = regexp.MustCompile(`'code': (?P<rpc_code>-?\d+)`)
+
+// ClassifyCliError return a CliError from liquidswap-cli output
+func ClassifyCliError(out shellexec.Output, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch err {
+	case shellexec.ErrTimeout, shellexec.ErrTimeoutKill:
+		return &CliError{
+			Kind:    CliErrorTimeout,
+			Message: err.Error(),
+			Code:    out.Code,
+			err:     common.ErrBackendUnavailable,
+		}
+
+	case shellexec.ErrInvalidProgram:
+		return common.ErrBackendUnavailable
+
+	case common.ErrNoOutput:
+		if len(strings.TrimSpace(out.Stderr)) == 0 {
+			return err
+		}
+	}
+
+	result := CliError{
+		Kind:    CliErrorUnknown,
+		Message: lastLine(out.Stderr),
+		Details: make(map[string]string),
+		Code:    out.Code,
+		err:     err,
+	}
+
+	for _, pattern := range cliErrorPatterns {
+		if !pattern.Match.MatchString(out.Stderr) {
+			continue
+		}
+		result.Kind = pattern.Kind
+		result.err = pattern.Err
+		extractDetails(result.Details, pattern.Details, out.Stderr)
+		break
+	}
+	extractDetails(result.Details, rpcCodePattern, out.Stderr)
+
+	return &result
+}
+
+func extractDetails(details map[string]string, pattern *regexp.Regexp, stderr string) {
+	if pattern == nil {
+		return
+	}
+	match := pattern.FindStringSubmatch(stderr)
+	if match == nil {
+		return
+	}
+	for i, name := range pattern.SubexpNames() {
+		if len(name) > 0 {
+			details[name] = match[i]
+		}
+	}
+}
+
+func lastLine(stderr string) string {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
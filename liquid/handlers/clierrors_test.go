@@ -0,0 +1,113 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/condensat/bank-core/utils/shellexec"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func TestClassifyCliError(t *testing.T) {
+	t.Parallel()
+
+	errExit := errors.New("exit status 1")
+
+	tests := []struct {
+		fixture     string
+		wantKind    CliErrorKind
+		wantErr     error
+		wantDetails map[string]string
+	}{
+		{"insufficient_balance.txt", CliErrorInsufficientBalance, common.ErrInsufficientFunds, map[string]string{
+			"asset":     "ce091c998b83c78bb71a632313ba3760f1763d9cfcffae02258ffa9865a37bd2",
+			"needed":    "1000.00000000",
+			"available": "12.50000000",
+		}},
+		{"insufficient_funds_rpc.txt", CliErrorInsufficientBalance, common.ErrInsufficientFunds, map[string]string{
+			"rpc_code": "-6",
+		}},
+		{"wallet_locked.txt", CliErrorWalletLocked, common.ErrWalletLocked, map[string]string{
+			"rpc_code": "-13",
+		}},
+		{"connection_refused.txt", CliErrorConnectionRefused, common.ErrBackendUnavailable, map[string]string{
+			"errno": "111",
+		}},
+		{"invalid_proposal.txt", CliErrorInvalidProposal, common.ErrInvalidPayload, map[string]string{
+			"field": "amount_p",
+		}},
+		{"asset_mismatch.txt", CliErrorAssetMismatch, common.ErrAssetMismatch, map[string]string{
+			"expected": "0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a",
+			"found":    "6f0279e9ed041c3d710a9f57d0c02928416460c4b722ae3457a11eec381c526d",
+		}},
+		{"fee_too_low.txt", CliErrorFeeTooLow, common.ErrFeeTooLow, map[string]string{
+			"fee":      "90",
+			"required": "150",
+			"rpc_code": "-26",
+		}},
+		{"unknown.txt", CliErrorUnknown, errExit, map[string]string{}},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.fixture, func(t *testing.T) {
+			t.Parallel()
+
+			stderr, err := ioutil.ReadFile(filepath.Join("testdata", "stderr", tt.fixture))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+
+			err = ClassifyCliError(shellexec.Output{Stderr: string(stderr), Code: 1}, errExit)
+
+			var cliError *CliError
+			if !errors.As(err, &cliError) {
+				t.Fatalf("ClassifyCliError() = %v, want CliError", err)
+			}
+			if cliError.Kind != tt.wantKind {
+				t.Errorf("ClassifyCliError() Kind = %v, want %v", cliError.Kind, tt.wantKind)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ClassifyCliError() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(cliError.Details, tt.wantDetails) {
+				t.Errorf("ClassifyCliError() Details = %v, want %v", cliError.Details, tt.wantDetails)
+			}
+			if len(cliError.Message) == 0 {
+				t.Errorf("ClassifyCliError() empty Message")
+			}
+		})
+	}
+}
+
+func TestClassifyCliError_Execute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{"nil", nil, nil},
+		{"timeout", shellexec.ErrTimeout, common.ErrBackendUnavailable},
+		{"notInstalled", shellexec.ErrInvalidProgram, common.ErrBackendUnavailable},
+		{"noOutput", common.ErrNoOutput, common.ErrNoOutput},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := ClassifyCliError(shellexec.Output{}, tt.err); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ClassifyCliError() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
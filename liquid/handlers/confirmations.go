@@ -0,0 +1,152 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SwapConfirmationsKey = "Key.SwapConfirmationsKey"
+	BroadcastTimeoutKey  = "Key.BroadcastTimeoutKey"
+
+	DefaultConfirmations        = 2
+	DefaultConfirmationInterval = time.Minute
+	// DefaultBroadcastTimeout is the delay before an unconfirmed transaction is considered dropped
+	DefaultBroadcastTimeout = 24 * time.Hour
+)
+
+// SwapConfirmationsContext set the confirmations count required for confirmed swaps
+func SwapConfirmationsContext(ctx context.Context, confirmations int) context.Context {
+	return context.WithValue(ctx, SwapConfirmationsKey, confirmations)
+}
+
+// SwapConfirmationsFromContext return DefaultConfirmations if not set
+func SwapConfirmationsFromContext(ctx context.Context) int {
+	if confirmations, ok := ctx.Value(SwapConfirmationsKey).(int); ok && confirmations > 0 {
+		return confirmations
+	}
+	return DefaultConfirmations
+}
+
+// BroadcastTimeoutContext set the delay before an unconfirmed transaction is considered dropped
+func BroadcastTimeoutContext(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, BroadcastTimeoutKey, timeout)
+}
+
+// BroadcastTimeoutFromContext return DefaultBroadcastTimeout if not set
+func BroadcastTimeoutFromContext(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(BroadcastTimeoutKey).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return DefaultBroadcastTimeout
+}
+
+// CheckConfirmations update broadcast swaps state from the wallet transactions,
+// and publish Confirmed or Failed events.
+// All instances check the same swaps, a transition already done by another instance is skipped.
+func CheckConfirmations(ctx context.Context) error {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.CheckConfirmations")
+
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return nil
+	}
+
+	records, err := store.List(ctx, state.SwapStateBroadcast)
+	if err != nil {
+		return err
+	}
+
+	required := SwapConfirmationsFromContext(ctx)
+	broadcastTimeout := BroadcastTimeoutFromContext(ctx)
+	for _, record := range records {
+		log := log.WithFields(logrus.Fields{
+			"SwapID": record.SwapID,
+			"TxID":   record.TxID,
+		})
+
+		_, backend, err := walletBackend(common.SwapWalletContext(ctx, record.Wallet))
+		if err != nil {
+			log.WithError(err).
+				WithField("Wallet", record.Wallet).
+				Warning("Wallet backend not found")
+			continue
+		}
+
+		confirmations, err := backend.Confirmations(ctx, record.TxID)
+		if err == nil && confirmations == 0 && time.Since(record.UpdatedAt) > broadcastTimeout {
+			err = common.ErrTxDropped
+		}
+
+		switch {
+		case errors.Is(err, common.ErrTxDropped) || errors.Is(err, common.ErrTxConflicted):
+			_, errState := transitionSwap(ctx, store, record.SwapID, state.SwapStateFailed, nil, err)
+			if errors.Is(errState, state.ErrInvalidTransition) {
+				log.Debug("Swap state already changed")
+				continue
+			}
+			if errState != nil {
+				log.WithError(errState).
+					Error("Failed to record swap state")
+				continue
+			}
+
+			log.WithError(err).
+				Warning("Swap transaction failed")
+
+		case err != nil:
+			log.WithError(err).
+				Warning("Failed to get transaction confirmations")
+
+		case confirmations >= required:
+			_, err := transitionSwap(ctx, store, record.SwapID, state.SwapStateConfirmed, func(record *state.SwapRecord) {
+				record.Confirmations = confirmations
+			}, nil)
+			if errors.Is(err, state.ErrInvalidTransition) {
+				log.Debug("Swap state already changed")
+				continue
+			}
+			if err != nil {
+				log.WithError(err).
+					Error("Failed to record swap state")
+				continue
+			}
+
+			log.WithField("Confirmations", confirmations).
+				Info("Swap transaction confirmed")
+		}
+	}
+	return nil
+}
+
+// WatchConfirmations check broadcast swaps every interval until ctx is done
+func WatchConfirmations(ctx context.Context, interval time.Duration) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.WatchConfirmations")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := CheckConfirmations(ctx); err != nil {
+				log.WithError(err).
+					Error("Failed to check confirmations")
+			}
+		}
+	}
+}
@@ -6,60 +6,100 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/condensat/bank-core"
 	"github.com/condensat/bank-core/logger"
 
 	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
 
 	"github.com/condensat/bank-core/cache"
 	"github.com/condensat/bank-core/messaging"
 
-	"github.com/condensat/bank-core/utils/shellexec"
-
 	"github.com/sirupsen/logrus"
 )
 
-func CreateSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate float64) (common.SwapProposal, error) {
+func CreateSwapProposal(ctx context.Context, swapID uint64, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) (common.SwapProposal, error) {
 	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.CreateSwapProposal")
 
 	log = log.WithField("SwapID", swapID)
 
-	if len(address) == 0 {
-		return common.SwapProposal{}, common.ErrInvalidProposal
+	// same deadline for queue wait, wallet lock and backend calls
+	ctxOperation, cancel := operationContext(ctx, common.SwapCreateProposalSubject)
+	defer cancel()
+
+	network := common.NetworkFromContext(ctx)
+	if err := address.Validate(network); err != nil {
+		log.WithError(err).
+			WithField("Address", address).
+			Error("Invalid Address")
+		return common.SwapProposal{}, common.ErrInvalidAddress
+	}
+	if feeRate == 0 {
+		feeRate = network.Params().DefaultFeeRate
 	}
 	if !proposal.Valid() {
 		return common.SwapProposal{}, common.ErrInvalidProposal
 	}
+	// backends expect AssetID
+	proposal, err := proposal.Resolve(common.DefaultAssetRegistry())
+	if err != nil {
+		return common.SwapProposal{}, common.ErrInvalidProposal
+	}
 
 	result := common.SwapProposal{
 		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
 		SwapID:    swapID,
 	}
+	result.Expiry = result.Timestamp.Add(ProposalTTLFromContext(ctx).TTL(proposal))
 
-	ShellExecLock.Lock()
-	defer ShellExecLock.Unlock()
+	wallet, backend, err := walletBackend(ctx)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", wallet).
+			Error("Wallet backend not found")
+		return common.SwapProposal{}, err
+	}
+	result.Wallet = wallet
 
-	out, err := shellexec.Execute(ctx,
-		LiquidSwapPropose(address, proposal, feeRate),
-	)
-	if len(out.Stdout) == 0 && err == nil {
-		err = errors.New("No Output")
+	lock, err := queueWallet(ctxOperation, wallet, common.SwapCreateProposalSubject)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to lock wallet")
+		return common.SwapProposal{}, err
 	}
+	defer lock.Unlock()
+
+	// repeated request return the previous result
+	fingerprint := requestFingerprint(wallet, address, proposal, feeRate)
+	previous, ok, err := previousResult(ctx, swapID, state.OperationCreate, fingerprint)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap operation already done for another request")
+		return common.SwapProposal{}, err
+	}
+	if ok {
+		log.Debug("Swap operation already done")
+		return previous, nil
+	}
+
+	err = checkSwapTransition(ctx, swapID, state.SwapStateProposed)
 	if err != nil {
 		log.WithError(err).
-			WithFields(logrus.Fields{
-				"Stdout": out.Stdout,
-				"Stderr": out.Stderr,
-				"Code":   out.Code,
-			}).
-			Error("out")
+			Error("Invalid swap state")
+		return common.SwapProposal{}, err
+	}
+
+	out, err := backend.Propose(ctxOperation, address, proposal, feeRate)
+	err = backendError(ctxOperation, err)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap Backend failed")
 		return result, err
 	}
 
-	result.Payload = common.Payload(out.Stdout)
+	result.Payload = out
 
 	if !result.Payload.Valid() {
 		log.WithError(common.ErrInvalidPayload).
@@ -68,6 +108,30 @@ func CreateSwapProposal(ctx context.Context, swapID uint64, address common.Confi
 		return common.SwapProposal{}, common.ErrInvalidPayload
 	}
 
+	// proposal is created, unspents are reserved and recorded even after the operation deadline
+	// so that a repeated request get the same proposal.
+	// Proposal unspents can not be selected until finalized, cancelled or expired
+	err = reserveUnspents(ctx, backend, swapID, wallet, result.Payload, result.Expiry)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to reserve proposal unspents")
+		return common.SwapProposal{}, err
+	}
+
+	err = recordSwapTransitions(ctx, swapID, func(record *state.SwapRecord) {
+		record.Wallet = wallet
+		record.Proposal = proposal
+		record.ExpiresAt = result.Expiry
+	}, state.SwapStateProposed)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to record swap state")
+		releaseUnspents(ctx, swapID)
+		return common.SwapProposal{}, err
+	}
+
+	saveResult(ctx, swapID, state.OperationCreate, fingerprint, result)
+
 	log.WithField("Result", result).
 		Debug("Create Swap Proposal")
 
@@ -80,21 +144,42 @@ func OnCreateSwapProposal(ctx context.Context, subject string, message *bank.Mes
 		"Subject": subject,
 	})
 
-	var request common.SwapProposal
+	var request common.CreateProposalRequest
 	return messaging.HandleRequest(ctx, message, &request,
 		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
 			log = log.WithFields(logrus.Fields{
 				"SwapID": request.SwapID,
 			})
 
-			response, err := CreateSwapProposal(ctx, request.SwapID, request.Address, request.Proposal, request.FeeRate)
+			err := common.CheckSchemaVersion(request.Version)
+			if err == nil {
+				err = request.Validate(common.NetworkFromContext(ctx))
+			}
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Invalid request")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result := errorResponse(request.SwapID, request.Wallet, err)
+				response := result.CreateProposalResponse()
+				return &response, nil
+			}
+
+			ctx = common.SwapWalletContext(ctx, request.Wallet)
+			result, err := CreateSwapProposal(ctx, request.SwapID, request.Address, request.Proposal, request.FeeRate)
 			if err != nil {
 				log.WithError(err).
 					Errorf("Failed to CreateSwapProposal")
-				return nil, cache.ErrInternalError
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result = errorResponse(request.SwapID, request.Wallet, err)
 			}
 
 			// create & return response
+			response := result.CreateProposalResponse()
 			return &response, nil
 		})
 }
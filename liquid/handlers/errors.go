@@ -0,0 +1,28 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"time"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+// errorResponse return the result with a SwapError for client.
+// Internal errors details are not exposed.
+func errorResponse(swapID uint64, wallet string, err error) common.SwapProposal {
+	return common.SwapProposal{
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+		SwapID:    swapID,
+		Wallet:    wallet,
+		Error:     common.NewSwapError(err),
+	}
+}
+
+// legacyRequest return true for SchemaVersion1 requests,
+// their replies have no Error and failures are returned as handler errors
+func legacyRequest(version int) bool {
+	return common.MessageVersion(version) == common.SchemaVersion1
+}
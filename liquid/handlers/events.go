@@ -0,0 +1,69 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/appcontext"
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+)
+
+// newSwapEvent return the event for the swap record current state
+func newSwapEvent(record state.SwapRecord, err error) common.SwapEvent {
+	return common.SwapEvent{
+		Timestamp:     time.Now().UTC().Truncate(time.Millisecond),
+		SwapID:        record.SwapID,
+		Wallet:        record.Wallet,
+		State:         string(record.State),
+		Proposal:      record.Proposal,
+		TxID:          record.TxID,
+		Confirmations: record.Confirmations,
+		Error:         common.NewSwapError(err),
+
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+		ExpiresAt: record.ExpiresAt,
+	}
+}
+
+// transitionSwap change swap state and publish the swap event, reason is the error for failed swaps
+func transitionSwap(ctx context.Context, store state.Store, swapID uint64, to state.SwapState, update state.UpdateFunc, reason error) (state.SwapRecord, error) {
+	record, err := store.Transition(ctx, swapID, to, update)
+	if err != nil {
+		return record, err
+	}
+	publishSwapEvent(ctx, newSwapEvent(record, reason))
+	return record, nil
+}
+
+// publishSwapEvent publish event on the state event subject, nothing is published without messaging
+func publishSwapEvent(ctx context.Context, event common.SwapEvent) {
+	nats := appcontext.Messaging(ctx)
+	if nats == nil {
+		return
+	}
+
+	// subscribers of each supported schema version receive the event
+	network := common.NetworkFromContext(ctx)
+	for _, version := range common.SupportedSchemaVersions {
+		event.Version = version
+		subject := network.Subject(common.SubjectVersion(common.SwapEventStateSubject(event.State), version))
+		message := bank.ToMessage(appcontext.AppName(ctx), &event)
+		err := nats.Publish(ctx, subject, message)
+		if err != nil {
+			logger.Logger(ctx).WithError(err).
+				WithField("Method", "Liquid.handler.publishSwapEvent").
+				WithField("Subject", subject).
+				WithField("SwapID", event.SwapID).
+				Warning("Failed to publish swap event")
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+)
+
+const (
+	ProposalTTLKey = "Key.ProposalTTLKey"
+)
+
+func ProposalTTLContext(ctx context.Context, ttl *common.ProposalTTL) context.Context {
+	return context.WithValue(ctx, ProposalTTLKey, ttl)
+}
+
+// ProposalTTLFromContext return DefaultProposalTTL for all pairs if not set
+func ProposalTTLFromContext(ctx context.Context) *common.ProposalTTL {
+	switch ttl := ctx.Value(ProposalTTLKey).(type) {
+	case *common.ProposalTTL:
+		return ttl
+
+	default:
+		return common.NewProposalTTL(common.DefaultProposalTTL)
+	}
+}
+
+// swapExpiry return the recorded swap expiry, zero if unknown
+func swapExpiry(ctx context.Context, swapID uint64) time.Time {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return time.Time{}
+	}
+	record, err := store.Get(ctx, swapID)
+	if err != nil {
+		return time.Time{}
+	}
+	return record.ExpiresAt
+}
+
+// checkExpiry return ErrProposalExpired and record the swap as expired if expiry is reached
+func checkExpiry(ctx context.Context, swapID uint64, expiry time.Time) error {
+	if !common.Expired(expiry) {
+		return nil
+	}
+
+	current, err := swapState(ctx, swapID)
+	if err == nil && current.CanTransition(state.SwapStateExpired) {
+		err = recordSwapTransitions(ctx, swapID, nil, state.SwapStateExpired)
+		if err == nil {
+			// expired proposal unspents are available again
+			releaseUnspents(ctx, swapID)
+		}
+	}
+	if err != nil {
+		logger.Logger(ctx).WithError(err).
+			WithField("SwapID", swapID).
+			Warning("Failed to record expired swap")
+	}
+	return common.ErrProposalExpired
+}
@@ -6,27 +6,32 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/condensat/bank-core"
 	"github.com/condensat/bank-core/logger"
 
 	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
 
 	"github.com/condensat/bank-core/cache"
 	"github.com/condensat/bank-core/messaging"
 
-	"github.com/condensat/bank-core/utils/shellexec"
-
 	"github.com/sirupsen/logrus"
 )
 
-func FinalizeSwapProposal(ctx context.Context, swapID uint64, payload common.Payload) (common.SwapProposal, error) {
+// FinalizeSwapProposal sign the accepted swap transaction.
+// Without broadcast the swap stay Finalized until BroadcastSwapTransaction,
+// or fail once the broadcast window is expired.
+func FinalizeSwapProposal(ctx context.Context, swapID uint64, payload common.Payload, broadcast bool) (common.SwapProposal, error) {
 	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.FinalizeSwapProposal")
 
 	log = log.WithField("SwapID", swapID)
 
+	// same deadline for queue wait, wallet lock and backend calls
+	ctxOperation, cancel := operationContext(ctx, common.SwapFinalizeProposalSubject)
+	defer cancel()
+
 	if !payload.Valid() {
 		log.WithError(common.ErrInvalidPayload).
 			WithField("Payload", payload).
@@ -39,33 +44,102 @@ func FinalizeSwapProposal(ctx context.Context, swapID uint64, payload common.Pay
 		SwapID:    swapID,
 	}
 
-	ShellExecLock.Lock()
-	defer ShellExecLock.Unlock()
+	wallet, backend, err := walletBackend(ctx)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", wallet).
+			Error("Wallet backend not found")
+		return common.SwapProposal{}, err
+	}
+	result.Wallet = wallet
+
+	lock, err := queueWallet(ctxOperation, wallet, common.SwapFinalizeProposalSubject)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to lock wallet")
+		return common.SwapProposal{}, err
+	}
+	defer lock.Unlock()
+
+	// repeated request return the previous result
+	fingerprint := requestFingerprint(wallet, payload, broadcast)
+	previous, ok, err := previousResult(ctx, swapID, state.OperationFinalize, fingerprint)
+	if err != nil {
+		log.WithError(err).
+			Error("Swap operation already done for another request")
+		return common.SwapProposal{}, err
+	}
+	if ok {
+		log.Debug("Swap operation already done")
+		return previous, nil
+	}
+
+	expiry := swapExpiry(ctx, swapID)
+	err = checkExpiry(ctx, swapID, expiry)
+	if err != nil {
+		log.WithError(err).
+			WithField("Expiry", expiry).
+			Error("Proposal expired")
+		return common.SwapProposal{}, err
+	}
+	result.Expiry = expiry
 
-	out, err := shellexec.Execute(ctx, LiquidSwapFinalize(payload))
-	if len(out.Stdout) == 0 && err == nil {
-		err = errors.New("No Output")
+	// proposer can only finalize an accepted swap
+	if payload.Stage() != common.PayloadStageAccepted {
+		log.WithError(state.ErrInvalidTransition).
+			Error("Swap not accepted")
+		return common.SwapProposal{}, state.ErrInvalidTransition
 	}
+	transitions, err := finalizeTransitions(ctx, swapID, broadcast)
+	if err != nil {
+		log.WithError(err).
+			Error("Invalid swap state")
+		return common.SwapProposal{}, err
+	}
+
+	// accepted transaction must pay the recorded proposal and only spend its reserved unspents
+	terms, err := swapTerms(ctx, swapID, wallet)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to get swap terms")
+		return common.SwapProposal{}, err
+	}
+
+	out, err := backend.Finalize(ctxOperation, payload, terms, broadcast)
+	err = backendError(ctxOperation, err)
 	if err != nil {
 		log.WithError(err).
-			WithFields(logrus.Fields{
-				"Stdout": out.Stdout,
-				"Stderr": out.Stderr,
-				"Code":   out.Code,
-			}).
-			Error("out")
+			Error("Swap Backend failed")
 		return result, err
 	}
 
-	result.Payload = common.Payload(out.Stdout)
+	result.Payload = out
 
-	if !result.Payload.Valid() {
+	// swap transaction is tracked by its id, Finalized and Broadcast states require it
+	finalized, err := result.Payload.Finalized()
+	if err != nil {
 		log.WithError(common.ErrInvalidPayload).
 			WithField("Payload", result.Payload).
 			Error("Invalid Payload")
 		return common.SwapProposal{}, common.ErrInvalidPayload
 	}
 
+	err = recordSwapTransitions(ctx, swapID, func(record *state.SwapRecord) {
+		record.TxID = finalized.TxID
+	}, transitions...)
+	if err != nil {
+		log.WithError(err).
+			Error("Failed to record swap state")
+		return common.SwapProposal{}, err
+	}
+
+	// proposal unspents are spent by the swap transaction
+	if broadcast {
+		releaseUnspents(ctx, swapID)
+	}
+
+	saveResult(ctx, swapID, state.OperationFinalize, fingerprint, result)
+
 	log.WithField("Result", result).
 		Debug("Finalize Swap Proposal")
 
@@ -78,21 +152,71 @@ func OnFinalizeSwapProposal(ctx context.Context, subject string, message *bank.M
 		"Subject": subject,
 	})
 
-	var request common.SwapProposal
+	var request common.FinalizeProposalRequest
 	return messaging.HandleRequest(ctx, message, &request,
 		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
 			log = log.WithFields(logrus.Fields{
 				"SwapID": request.SwapID,
 			})
 
-			response, err := FinalizeSwapProposal(ctx, request.SwapID, request.Payload)
+			err := common.CheckSchemaVersion(request.Version)
+			if err == nil {
+				err = request.Validate(common.NetworkFromContext(ctx))
+			}
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Invalid request")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result := errorResponse(request.SwapID, request.Wallet, err)
+				response := result.FinalizeProposalResponse()
+				return &response, nil
+			}
+
+			ctx = common.SwapWalletContext(ctx, request.Wallet)
+			result, err := FinalizeSwapProposal(ctx, request.SwapID, request.Payload, !request.SkipBroadcast)
 			if err != nil {
 				log.WithError(err).
 					Errorf("Failed to FinalizeSwapProposal")
-				return nil, cache.ErrInternalError
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result = errorResponse(request.SwapID, request.Wallet, err)
 			}
 
 			// create & return response
+			response := result.FinalizeProposalResponse()
 			return &response, nil
 		})
 }
+
+// finalizeTransitions return transitions to apply once the swap is finalized, and sent if broadcast is set.
+// Acceptance is only known by the proposer when finalize is requested.
+func finalizeTransitions(ctx context.Context, swapID uint64, broadcast bool) ([]state.SwapState, error) {
+	if state.SwapStoreFromContext(ctx) == nil {
+		return nil, nil
+	}
+
+	current, err := swapState(ctx, swapID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []state.SwapState
+	switch current {
+	case state.SwapStateProposed:
+		result = []state.SwapState{state.SwapStateAccepted, state.SwapStateFinalized}
+	case state.SwapStateAccepted:
+		result = []state.SwapState{state.SwapStateFinalized}
+
+	default:
+		return nil, state.ErrInvalidTransition
+	}
+
+	if broadcast {
+		result = append(result, state.SwapStateBroadcast)
+	}
+	return result, nil
+}
@@ -1,11 +0,0 @@
-// Copyright 2020 Condensat Tech. All rights reserved.
-// Use of this source code is governed by a MIT
-// license that can be found in the LICENSE file.
-
-package handlers
-
-import (
-	"sync"
-)
-
-var ShellExecLock sync.Mutex
@@ -0,0 +1,1188 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/appcontext"
+	"github.com/condensat/bank-core/cache"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/fake"
+	"github.com/condensat/bank-swap/liquid/state"
+)
+
+const (
+	assetUSDt = common.AssetID("ce091c998b83c78bb71a632313ba3760f1763d9cfcffae02258ffa9865a37bd2")
+	assetLCAD = common.AssetID("0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a")
+
+	testAddress        = common.ConfidentialAddress("lq1qqv8ymngmdp5yj2jukdd78ujm92m0wjvk7yxplra2haf5dnuzsutz96dvvqscm0raftaljf9p30wg4sd2alht5epuyn2fe7vn6")
+	testAddressTestnet = common.ConfidentialAddress("tlq1qq2p9fsef4y59pak488wnwm6gzmhzwez30kj7qg64zjh5xvtyfqxh59yda8z60fzdr8jkekdwrf25hancg7hmq49h6u682pdm6")
+)
+
+type swapParties struct {
+	proposer    *fake.Engine
+	acceptor    *fake.Engine
+	proposerCtx context.Context
+	acceptorCtx context.Context
+}
+
+func newSwapParties() swapParties {
+	chain := fake.NewChain()
+
+	proposer := fake.NewEngine(chain, "proposer")
+	proposer.Fund(string(assetUSDt), 1500)
+
+	acceptor := fake.NewEngine(chain, "acceptor")
+	acceptor.Fund(string(assetLCAD), 1000)
+	acceptor.Fund(string(assetLCAD), 1000)
+	acceptor.Fund(fake.PolicyAsset, 10000)
+
+	ctx := context.Background()
+	return swapParties{
+		proposer:    proposer,
+		acceptor:    acceptor,
+		proposerCtx: state.SwapStoreContext(SwapBackendContext(ctx, proposer), state.NewMemoryStore()),
+		acceptorCtx: state.SwapStoreContext(SwapBackendContext(ctx, acceptor), state.NewMemoryStore()),
+	}
+}
+
+func handleRequest(ctx context.Context, handler bank.MessageHandler, request common.SwapProposal) (common.SwapProposal, error) {
+	message, err := handler(ctx, "test", bank.ToMessage("test", &request))
+	if err != nil {
+		return common.SwapProposal{}, err
+	}
+
+	var result common.SwapProposal
+	err = bank.FromMessage(message, &result)
+	if err != nil {
+		return common.SwapProposal{}, err
+	}
+	if result.Error != nil {
+		return result, result.Error
+	}
+	return result, nil
+}
+
+func TestSwapProposalFlow(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	proposerAddress := parties.proposer.NewAddress()
+	created, err := handleRequest(parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{
+		SwapID:   42,
+		Address:  proposerAddress,
+		Proposal: proposal,
+		FeeRate:  common.DefaultFeeRate,
+	})
+	if err != nil {
+		t.Fatalf("OnCreateSwapProposal() error = %v", err)
+	}
+	if created.SwapID != 42 || !created.Payload.Valid() {
+		t.Fatalf("OnCreateSwapProposal() invalid response %+v", created)
+	}
+
+	// repeated request must not lock other funds
+	repeated, err := CreateSwapProposal(parties.proposerCtx, 42, proposerAddress, proposal, common.DefaultFeeRate)
+	if err != nil || repeated.Payload != created.Payload {
+		t.Fatalf("CreateSwapProposal() repeated = %v, %v, want %v", repeated.Payload, err, created.Payload)
+	}
+	// other request for the same swap is a conflict
+	_, err = CreateSwapProposal(parties.proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != common.ErrRequestConflict {
+		t.Fatalf("CreateSwapProposal() other request error = %v, want %v", err, common.ErrRequestConflict)
+	}
+
+	info, err := handleRequest(parties.acceptorCtx, OnInfoSwapProposal, common.SwapProposal{
+		SwapID:  42,
+		Payload: created.Payload,
+	})
+	if err != nil {
+		t.Fatalf("OnInfoSwapProposal() error = %v", err)
+	}
+	if !info.Payload.Valid() {
+		t.Fatalf("OnInfoSwapProposal() invalid payload %v", info.Payload)
+	}
+	if info.Info == nil || !info.Info.IsProposer() || info.Info.Locked {
+		t.Fatalf("OnInfoSwapProposal() invalid info %+v", info.Info)
+	}
+	if info.Info.ProposerAsset != assetUSDt || info.Info.ReceiverAmount != proposal.ReceiverAmount {
+		t.Errorf("OnInfoSwapProposal() wrong info %+v", info.Info)
+	}
+
+	accepted, err := handleRequest(parties.acceptorCtx, OnAcceptSwapProposal, common.SwapProposal{
+		SwapID:  42,
+		Address: parties.acceptor.NewAddress(),
+		Payload: created.Payload,
+		FeeRate: common.DefaultFeeRate,
+	})
+	if err != nil {
+		t.Fatalf("OnAcceptSwapProposal() error = %v", err)
+	}
+
+	acceptedInfo, err := InfoSwapProposal(parties.proposerCtx, 42, accepted.Payload)
+	if err != nil {
+		t.Fatalf("InfoSwapProposal() error = %v", err)
+	}
+	if !acceptedInfo.Info.IsAcceptor() || !acceptedInfo.Info.Locked || acceptedInfo.Info.Fee <= 0 {
+		t.Errorf("InfoSwapProposal() wrong accepted info %+v", acceptedInfo.Info)
+	}
+
+	finalized, err := handleRequest(parties.proposerCtx, OnFinalizeSwapProposal, common.SwapProposal{
+		SwapID:  42,
+		Payload: accepted.Payload,
+	})
+	if err != nil {
+		t.Fatalf("OnFinalizeSwapProposal() error = %v", err)
+	}
+	if !finalized.Payload.Valid() {
+		t.Fatalf("OnFinalizeSwapProposal() invalid payload %v", finalized.Payload)
+	}
+
+	states := []struct {
+		ctx  context.Context
+		want state.SwapState
+	}{
+		{parties.proposerCtx, state.SwapStateBroadcast},
+		{parties.acceptorCtx, state.SwapStateAccepted},
+	}
+	for _, s := range states {
+		record, err := state.SwapStoreFromContext(s.ctx).Get(s.ctx, 42)
+		if err != nil {
+			t.Fatalf("Store.Get() error = %v", err)
+		}
+		if record.State != s.want || record.Proposal.ReceiverAmount != proposal.ReceiverAmount {
+			t.Errorf("Store.Get() = %+v, want state %v", record, s.want)
+		}
+	}
+	if record, _ := state.SwapStoreFromContext(parties.proposerCtx).Get(parties.proposerCtx, 42); len(record.TxID) == 0 {
+		t.Errorf("Store.Get() missing TxID %+v", record)
+	}
+
+	repeated, err = FinalizeSwapProposal(parties.proposerCtx, 42, accepted.Payload, true)
+	if err != nil || repeated.Payload != finalized.Payload {
+		t.Errorf("FinalizeSwapProposal() repeated = %v, %v, want %v", repeated.Payload, err, finalized.Payload)
+	}
+
+	balances := []struct {
+		engine *fake.Engine
+		asset  common.AssetID
+		want   int64
+	}{
+		{parties.proposer, assetUSDt, 500},
+		{parties.proposer, assetLCAD, 1400},
+		{parties.acceptor, assetUSDt, 1000},
+		{parties.acceptor, assetLCAD, 600},
+		{parties.acceptor, fake.PolicyAsset, 10000 - fake.DefaultFee},
+	}
+	for _, balance := range balances {
+		if got := balance.engine.Balance(string(balance.asset)); got != balance.want {
+			t.Errorf("%s balance %s = %v, want %v", balance.engine.Name, balance.asset[:8], got, balance.want)
+		}
+	}
+}
+
+func TestSwapProposalHandlers(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	valid := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+	tooMuch := valid
+	tooMuch.ProposerAmount = 2000
+
+	created, err := CreateSwapProposal(parties.proposerCtx, 1, testAddress, valid, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 1, testAddress, created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+	// funds for one more proposal, swap 1 unspents are reserved
+	parties.proposer.Fund(string(assetUSDt), 1000)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		handler bank.MessageHandler
+		request common.SwapProposal
+		wantErr bool
+	}{
+		{"noBackend", context.Background(), OnCreateSwapProposal, common.SwapProposal{Address: testAddress, Proposal: valid}, true},
+
+		{"create", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{Address: testAddress, Proposal: valid}, false},
+		{"createReserved", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{SwapID: 5, Address: testAddress, Proposal: valid}, true},
+		{"createNoAddress", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{Proposal: valid}, true},
+		{"createWrongNetwork", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{SwapID: 4, Address: testAddressTestnet, Proposal: valid}, true},
+		{"createInvalidAddress", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{SwapID: 4, Address: "address", Proposal: valid}, true},
+		{"createInvalidProposal", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{Address: testAddress}, true},
+		{"createInsufficientFunds", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{SwapID: 2, Address: testAddress, Proposal: tooMuch}, true},
+		{"createRepeated", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{SwapID: 1, Address: testAddress, Proposal: valid, FeeRate: common.DefaultFeeRate}, false},
+		{"createConflict", parties.proposerCtx, OnCreateSwapProposal, common.SwapProposal{SwapID: 1, Address: testAddress, Proposal: tooMuch, FeeRate: common.DefaultFeeRate}, true},
+		{"createAlreadyAccepted", parties.acceptorCtx, OnCreateSwapProposal, common.SwapProposal{SwapID: 1, Address: testAddress, Proposal: valid}, true},
+
+		{"info", parties.acceptorCtx, OnInfoSwapProposal, common.SwapProposal{Payload: created.Payload}, false},
+		{"infoInvalidPayload", parties.acceptorCtx, OnInfoSwapProposal, common.SwapProposal{Payload: "invalid"}, true},
+
+		{"acceptNoAddress", parties.acceptorCtx, OnAcceptSwapProposal, common.SwapProposal{SwapID: 4, Payload: created.Payload}, true},
+		{"acceptInvalidPayload", parties.acceptorCtx, OnAcceptSwapProposal, common.SwapProposal{Address: testAddress, Payload: "invalid"}, true},
+		{"acceptNotProposal", parties.acceptorCtx, OnAcceptSwapProposal, common.SwapProposal{Address: testAddress, Payload: `{"protocol_version": 1}`}, true},
+		{"acceptRepeated", parties.acceptorCtx, OnAcceptSwapProposal, common.SwapProposal{SwapID: 1, Address: testAddress, Payload: created.Payload, FeeRate: common.DefaultFeeRate, Expiry: created.Expiry}, false},
+		{"acceptConflict", parties.acceptorCtx, OnAcceptSwapProposal, common.SwapProposal{SwapID: 1, Address: testAddress, Payload: created.Payload, FeeRate: 2 * common.DefaultFeeRate, Expiry: created.Expiry}, true},
+
+		{"finalizeInvalidPayload", parties.proposerCtx, OnFinalizeSwapProposal, common.SwapProposal{Payload: "invalid"}, true},
+		{"finalizeNotAccepted", parties.proposerCtx, OnFinalizeSwapProposal, common.SwapProposal{SwapID: 1, Payload: created.Payload}, true},
+		{"finalizeNeverProposed", parties.proposerCtx, OnFinalizeSwapProposal, common.SwapProposal{SwapID: 3, Payload: accepted.Payload}, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handleRequest(tt.ctx, tt.handler, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("handler error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSwapProposalHandlers_Version(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+
+	tests := []struct {
+		name      string
+		version   int
+		wantErr   error
+		wantReply bool
+	}{
+		{"v1", common.SchemaVersion1, cache.ErrInternalError, false},
+		{"v2", common.SchemaVersion2, nil, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			request := common.SwapProposal{Version: tt.version, SwapID: 1, Payload: "invalid"}
+			message, err := OnInfoSwapProposal(parties.acceptorCtx, "test", bank.ToMessage("test", &request))
+			if err != tt.wantErr {
+				t.Fatalf("OnInfoSwapProposal() error = %v, want %v", err, tt.wantErr)
+			}
+			if (message != nil) != tt.wantReply {
+				t.Fatalf("OnInfoSwapProposal() reply = %v, want %v", message != nil, tt.wantReply)
+			}
+			if message == nil {
+				return
+			}
+
+			var reply common.SwapProposal
+			if err := bank.FromMessage(message, &reply); err != nil {
+				t.Fatalf("FromMessage() error = %v", err)
+			}
+			if reply.Error == nil || reply.Error.Code != common.ErrorCodeInvalidPayload {
+				t.Errorf("OnInfoSwapProposal() reply Error = %+v, want %v", reply.Error, common.ErrorCodeInvalidPayload)
+			}
+		})
+	}
+}
+
+func TestSwapProposalWallets(t *testing.T) {
+	t.Parallel()
+
+	chain := fake.NewChain()
+	customer := fake.NewEngine(chain, "customer")
+	treasury := fake.NewEngine(chain, "treasury")
+	treasury.Fund(string(assetUSDt), 1000)
+
+	wallets := NewWalletRegistry("customer")
+	wallets.Register("customer", customer)
+	wallets.Register("treasury", treasury)
+
+	ctx := state.SwapStoreContext(SwapWalletsContext(context.Background(), wallets), state.NewMemoryStore())
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	tests := []struct {
+		name       string
+		swapID     uint64
+		wallet     string
+		wantWallet string
+		wantErr    error
+	}{
+		{"defaultNoFunds", 1, "", "", common.ErrInsufficientFunds},
+		{"unknown", 2, "unknown", "unknown", common.ErrWalletNotFound},
+		{"treasury", 3, "treasury", "treasury", nil},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := handleRequest(ctx, OnCreateSwapProposal, common.SwapProposal{
+				SwapID:   tt.swapID,
+				Address:  testAddress,
+				Proposal: proposal,
+				Wallet:   tt.wallet,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("OnCreateSwapProposal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Wallet != tt.wantWallet {
+				t.Errorf("OnCreateSwapProposal() Wallet = %v, want %v", got.Wallet, tt.wantWallet)
+			}
+		})
+	}
+
+	record, err := state.SwapStoreFromContext(ctx).Get(ctx, 3)
+	if err != nil || record.Wallet != "treasury" {
+		t.Errorf("Store.Get() = %+v, %v, want treasury wallet", record, err)
+	}
+}
+
+func TestSwapProposalExpiry(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	const ttl = 20 * time.Millisecond
+	proposalTTL := common.NewProposalTTL(time.Hour)
+	proposalTTL.SetPair(assetLCAD, assetUSDt, ttl)
+	proposerCtx := ProposalTTLContext(parties.proposerCtx, proposalTTL)
+
+	created, err := CreateSwapProposal(proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	if !created.Expiry.Equal(created.Timestamp.Add(ttl)) {
+		t.Errorf("CreateSwapProposal() Expiry = %v, want %v", created.Expiry, created.Timestamp.Add(ttl))
+	}
+
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+
+	time.Sleep(time.Until(created.Expiry))
+
+	_, err = AcceptSwapProposal(parties.acceptorCtx, 43, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != common.ErrProposalExpired {
+		t.Errorf("AcceptSwapProposal() error = %v, want %v", err, common.ErrProposalExpired)
+	}
+
+	_, err = FinalizeSwapProposal(proposerCtx, 42, accepted.Payload, true)
+	if err != common.ErrProposalExpired {
+		t.Errorf("FinalizeSwapProposal() error = %v, want %v", err, common.ErrProposalExpired)
+	}
+	record, err := state.SwapStoreFromContext(proposerCtx).Get(proposerCtx, 42)
+	if err != nil || record.State != state.SwapStateExpired {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateExpired)
+	}
+}
+
+func TestSwapProposalExpiryNotExtended(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	created, err := CreateSwapProposal(parties.proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+
+	store := state.SwapStoreFromContext(parties.acceptorCtx)
+	recordExpiry := func(swapID uint64, expiry time.Time) {
+		_, err := store.Transition(parties.acceptorCtx, swapID, state.SwapStateProposed, func(record *state.SwapRecord) {
+			record.ExpiresAt = expiry
+		})
+		if err != nil {
+			t.Fatalf("Store.Transition() error = %v", err)
+		}
+	}
+
+	// later requested expiry is ignored
+	stored := time.Now().Add(time.Minute).UTC().Truncate(time.Millisecond)
+	recordExpiry(42, stored)
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, stored.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+	if !accepted.Expiry.Equal(stored) {
+		t.Errorf("AcceptSwapProposal() Expiry = %v, want %v", accepted.Expiry, stored)
+	}
+	record, err := store.Get(parties.acceptorCtx, 42)
+	if err != nil || !record.ExpiresAt.Equal(stored) {
+		t.Errorf("Store.Get() ExpiresAt = %v, %v, want %v", record.ExpiresAt, err, stored)
+	}
+
+	// expired proposal can not be extended
+	expired := time.Now().Add(-time.Second)
+	recordExpiry(43, expired)
+	_, err = AcceptSwapProposal(parties.acceptorCtx, 43, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, time.Now().Add(time.Hour))
+	if err != common.ErrProposalExpired {
+		t.Errorf("AcceptSwapProposal() error = %v, want %v", err, common.ErrProposalExpired)
+	}
+	record, err = store.Get(parties.acceptorCtx, 43)
+	if err != nil || record.State != state.SwapStateExpired || !record.ExpiresAt.Equal(expired) {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateExpired)
+	}
+}
+
+func TestSwapProposalCancel(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	parties.proposer.Fund(fake.PolicyAsset, 1000)
+
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	created, err := CreateSwapProposal(parties.proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+
+	_, err = CancelSwapProposal(parties.proposerCtx, 42, accepted.Payload, common.DefaultFeeRate)
+	if err != state.ErrInvalidTransition {
+		t.Errorf("CancelSwapProposal() accepted payload error = %v, want %v", err, state.ErrInvalidTransition)
+	}
+
+	cancelled, err := CancelSwapProposal(parties.proposerCtx, 42, created.Payload, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CancelSwapProposal() error = %v", err)
+	}
+	document, err := cancelled.Payload.Cancelled()
+	if err != nil {
+		t.Fatalf("Payload.Cancelled() error = %v", err)
+	}
+	if _, ok := parties.proposer.Chain.Transaction(document.CancelTxID); !ok {
+		t.Errorf("Chain.Transaction() %s not found", document.CancelTxID)
+	}
+
+	repeated, err := CancelSwapProposal(parties.proposerCtx, 42, created.Payload, common.DefaultFeeRate)
+	if err != nil || repeated.Payload != cancelled.Payload {
+		t.Errorf("CancelSwapProposal() repeated = %v, %v, want %v", repeated.Payload, err, cancelled.Payload)
+	}
+
+	record, err := state.SwapStoreFromContext(parties.proposerCtx).Get(parties.proposerCtx, 42)
+	if err != nil || record.State != state.SwapStateCancelled || record.TxID != document.CancelTxID {
+		t.Errorf("Store.Get() = %+v, %v, want %v with TxID %s", record, err, state.SwapStateCancelled, document.CancelTxID)
+	}
+
+	_, err = FinalizeSwapProposal(parties.proposerCtx, 42, accepted.Payload, true)
+	if err != state.ErrInvalidTransition {
+		t.Errorf("FinalizeSwapProposal() error = %v, want %v", err, state.ErrInvalidTransition)
+	}
+
+	if got := parties.proposer.Balance(string(assetUSDt)); got != 1500 {
+		t.Errorf("Balance() USDt = %v, want %v", got, 1500)
+	}
+	if got := parties.proposer.Balance(fake.PolicyAsset); got != 1000-fake.DefaultFee {
+		t.Errorf("Balance() policy = %v, want %v", got, 1000-fake.DefaultFee)
+	}
+
+	// a new swap can not spend cancelled proposal inputs
+	_, err = CancelSwapProposal(parties.proposerCtx, 43, created.Payload, common.DefaultFeeRate)
+	if err != common.ErrInvalidSwapState {
+		t.Errorf("CancelSwapProposal() spent inputs error = %v, want %v", err, common.ErrInvalidSwapState)
+	}
+}
+
+func TestSwapProposalTermsMismatch(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	created, err := CreateSwapProposal(parties.proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+
+	// acceptor lower the proposer requested output
+	document, _ := accepted.Payload.Accepted()
+	tx, _ := fake.DecodeTransaction(document.Tx)
+	for i, output := range tx.Outputs {
+		if output.Asset == string(assetLCAD) && output.Amount == 1400 {
+			tx.Outputs[i].Amount = 1000
+		}
+	}
+	document.Tx = tx.Encode()
+	tampered, _ := common.EncodePayload(&document, common.PayloadBase64)
+
+	_, err = FinalizeSwapProposal(parties.proposerCtx, 42, tampered, true)
+	if err != common.ErrTermsMismatch {
+		t.Fatalf("FinalizeSwapProposal() error = %v, want %v", err, common.ErrTermsMismatch)
+	}
+	if _, ok := parties.proposer.Chain.Transaction(tx.TxID()); ok {
+		t.Errorf("Chain.Transaction() tampered transaction %s broadcasted", tx.TxID())
+	}
+
+	// accepted transaction is still valid
+	_, err = FinalizeSwapProposal(parties.proposerCtx, 42, accepted.Payload, true)
+	if err != nil {
+		t.Errorf("FinalizeSwapProposal() error = %v", err)
+	}
+}
+
+func TestSwapProposalReservation(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	const ttl = 20 * time.Millisecond
+	proposalTTL := common.NewProposalTTL(time.Hour)
+	proposalTTL.SetPair(assetLCAD, assetUSDt, ttl)
+	proposerCtx := ProposalTTLContext(parties.proposerCtx, proposalTTL)
+
+	created, err := CreateSwapProposal(proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+
+	// concurrent proposal can not select reserved unspents
+	_, err = CreateSwapProposal(proposerCtx, 43, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != common.ErrInsufficientFunds {
+		t.Errorf("CreateSwapProposal() error = %v, want %v", err, common.ErrInsufficientFunds)
+	}
+
+	balances, err := SwapBalances(proposerCtx)
+	want := []common.AssetBalance{{Asset: assetUSDt, Spendable: 0, Reserved: 1500}}
+	if err != nil || !reflect.DeepEqual(balances.Balances, want) {
+		t.Errorf("SwapBalances() = %+v, %v, want %+v", balances.Balances, err, want)
+	}
+
+	time.Sleep(time.Until(created.Expiry))
+	if err := ReleaseExpiredReservations(proposerCtx); err != nil {
+		t.Fatalf("ReleaseExpiredReservations() error = %v", err)
+	}
+
+	balances, err = SwapBalances(proposerCtx)
+	want = []common.AssetBalance{{Asset: assetUSDt, Spendable: 1500, Reserved: 0}}
+	if err != nil || !reflect.DeepEqual(balances.Balances, want) {
+		t.Errorf("SwapBalances() = %+v, %v, want %+v", balances.Balances, err, want)
+	}
+	record, err := state.SwapStoreFromContext(proposerCtx).Get(proposerCtx, 42)
+	if err != nil || record.State != state.SwapStateExpired {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateExpired)
+	}
+
+	if _, err := CreateSwapProposal(proposerCtx, 43, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate); err != nil {
+		t.Errorf("CreateSwapProposal() released error = %v", err)
+	}
+}
+
+func TestSwapProposalBroadcastWindow(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	store := state.SwapStoreFromContext(parties.proposerCtx)
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	const ttl = 50 * time.Millisecond
+	proposalTTL := common.NewProposalTTL(time.Hour)
+	proposalTTL.SetPair(assetLCAD, assetUSDt, ttl)
+	proposerCtx := ProposalTTLContext(parties.proposerCtx, proposalTTL)
+
+	created, err := CreateSwapProposal(proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+	signed, err := FinalizeSwapProposal(proposerCtx, 42, accepted.Payload, false)
+	if err != nil {
+		t.Fatalf("FinalizeSwapProposal() error = %v", err)
+	}
+
+	// finalized swap keep its unspents during the broadcast window
+	time.Sleep(time.Until(created.Expiry))
+	if err := ReleaseExpiredReservations(BroadcastWindowContext(proposerCtx, time.Hour)); err != nil {
+		t.Fatalf("ReleaseExpiredReservations() error = %v", err)
+	}
+	if reservations, _ := store.Reservations(proposerCtx, ""); len(reservations) != 1 {
+		t.Errorf("Store.Reservations() = %+v, want unspents reserved during broadcast window", reservations)
+	}
+
+	// finalized swap stay reserved until its inputs are spent back
+	proposerCtx = BroadcastWindowContext(proposerCtx, time.Nanosecond)
+	if err := ReleaseExpiredReservations(proposerCtx); err != nil {
+		t.Fatalf("ReleaseExpiredReservations() error = %v", err)
+	}
+	if reservations, _ := store.Reservations(proposerCtx, ""); len(reservations) != 1 {
+		t.Errorf("Store.Reservations() = %+v, want unspents reserved without cancel fee", reservations)
+	}
+	if record, err := store.Get(proposerCtx, 42); err != nil || record.State != state.SwapStateFinalized {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateFinalized)
+	}
+
+	parties.proposer.Fund(fake.PolicyAsset, 1000)
+	if err := ReleaseExpiredReservations(proposerCtx); err != nil {
+		t.Fatalf("ReleaseExpiredReservations() error = %v", err)
+	}
+	if reservations, _ := store.Reservations(proposerCtx, ""); len(reservations) != 0 {
+		t.Errorf("Store.Reservations() = %+v, want none", reservations)
+	}
+	record, err := store.Get(proposerCtx, 42)
+	if err != nil || record.State != state.SwapStateFailed {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateFailed)
+	}
+
+	_, err = BroadcastSwapTransaction(proposerCtx, 42, signed.Payload)
+	if err != common.ErrInvalidSwapState {
+		t.Errorf("BroadcastSwapTransaction() error = %v, want %v", err, common.ErrInvalidSwapState)
+	}
+
+	// signed transaction inputs are spent back, the acceptor can not send it anymore
+	finalized, _ := signed.Payload.Finalized()
+	if _, err := parties.acceptor.Broadcast(parties.acceptorCtx, finalized.Tx); err != fake.ErrMissingInputs {
+		t.Errorf("Broadcast() error = %v, want %v", err, fake.ErrMissingInputs)
+	}
+}
+
+func TestSwapProposalBroadcastWindow_Sent(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	store := state.SwapStoreFromContext(parties.proposerCtx)
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	const ttl = 50 * time.Millisecond
+	proposalTTL := common.NewProposalTTL(time.Hour)
+	proposalTTL.SetPair(assetLCAD, assetUSDt, ttl)
+	proposerCtx := ProposalTTLContext(parties.proposerCtx, proposalTTL)
+
+	created, err := CreateSwapProposal(proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+	signed, err := FinalizeSwapProposal(proposerCtx, 42, accepted.Payload, false)
+	if err != nil {
+		t.Fatalf("FinalizeSwapProposal() error = %v", err)
+	}
+
+	// signed transaction sent by the acceptor during the broadcast window
+	finalized, _ := signed.Payload.Finalized()
+	if _, err := parties.acceptor.Broadcast(parties.acceptorCtx, finalized.Tx); err != nil {
+		t.Fatalf("Broadcast() error = %v", err)
+	}
+
+	time.Sleep(time.Until(created.Expiry))
+	proposerCtx = BroadcastWindowContext(proposerCtx, time.Nanosecond)
+	if err := ReleaseExpiredReservations(proposerCtx); err != nil {
+		t.Fatalf("ReleaseExpiredReservations() error = %v", err)
+	}
+	if reservations, _ := store.Reservations(proposerCtx, ""); len(reservations) != 0 {
+		t.Errorf("Store.Reservations() = %+v, want none", reservations)
+	}
+	record, err := store.Get(proposerCtx, 42)
+	if err != nil || record.State != state.SwapStateBroadcast || record.TxID != finalized.TxID {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateBroadcast)
+	}
+}
+
+func TestSwapProposalConfirmations(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	messaging := newTestMessaging()
+	proposerCtx := appcontext.WithMessaging(parties.proposerCtx, messaging)
+	store := state.SwapStoreFromContext(proposerCtx)
+
+	swap := func(swapID uint64, proposal common.ProposalInfo) string {
+		created, err := CreateSwapProposal(proposerCtx, swapID, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+		if err != nil {
+			t.Fatalf("CreateSwapProposal() error = %v", err)
+		}
+		accepted, err := AcceptSwapProposal(parties.acceptorCtx, swapID, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+		if err != nil {
+			t.Fatalf("AcceptSwapProposal() error = %v", err)
+		}
+		finalized, err := FinalizeSwapProposal(proposerCtx, swapID, accepted.Payload, true)
+		if err != nil {
+			t.Fatalf("FinalizeSwapProposal() error = %v", err)
+		}
+		document, _ := finalized.Payload.Finalized()
+		return document.TxID
+	}
+	checkState := func(swapID uint64, want state.SwapState) {
+		t.Helper()
+		if err := CheckConfirmations(proposerCtx); err != nil {
+			t.Fatalf("CheckConfirmations() error = %v", err)
+		}
+		record, err := store.Get(proposerCtx, swapID)
+		if err != nil || record.State != want {
+			t.Errorf("Store.Get() = %+v, %v, want %v", record, err, want)
+		}
+	}
+
+	txID := swap(42, common.ProposalInfo{ProposerAsset: assetUSDt, ProposerAmount: 1000, ReceiverAsset: assetLCAD, ReceiverAmount: 1400})
+	checkState(42, state.SwapStateBroadcast)
+	parties.proposer.Chain.Mine(1)
+	checkState(42, state.SwapStateBroadcast)
+	parties.proposer.Chain.Mine(1)
+	checkState(42, state.SwapStateConfirmed)
+
+	events := messaging.Events(common.SwapEventStateSubject(string(state.SwapStateConfirmed)))
+	if len(events) != 1 || events[0].SwapID != 42 || events[0].TxID != txID || events[0].Confirmations != DefaultConfirmations {
+		t.Errorf("Confirmed events = %+v, want swap 42 with %s", events, txID)
+	}
+
+	// another instance already confirmed the swap
+	stale, _ := store.Get(proposerCtx, 42)
+	stale.State = state.SwapStateBroadcast
+	staleCtx := state.SwapStoreContext(proposerCtx, &staleStore{Store: store, records: []state.SwapRecord{stale}})
+	if err := CheckConfirmations(staleCtx); err != nil {
+		t.Fatalf("CheckConfirmations() stale error = %v", err)
+	}
+	if events := messaging.Events(common.SwapEventStateSubject(string(state.SwapStateConfirmed))); len(events) != 1 {
+		t.Errorf("Confirmed events = %d, want 1", len(events))
+	}
+
+	txID = swap(43, common.ProposalInfo{ProposerAsset: assetUSDt, ProposerAmount: 400, ReceiverAsset: assetLCAD, ReceiverAmount: 500})
+	parties.proposer.Chain.Drop(txID)
+	checkState(43, state.SwapStateFailed)
+
+	events = messaging.Events(common.SwapEventStateSubject(string(state.SwapStateFailed)))
+	if len(events) != 1 || events[0].SwapID != 43 || !errors.Is(events[0].Error, common.ErrTxDropped) {
+		t.Errorf("Failed events = %+v, want swap 43 with %v", events, common.ErrTxDropped)
+	}
+
+	// unconfirmed transaction fail after the broadcast timeout
+	swap(44, common.ProposalInfo{ProposerAsset: assetUSDt, ProposerAmount: 50, ReceiverAsset: assetLCAD, ReceiverAmount: 60})
+	checkState(44, state.SwapStateBroadcast)
+	proposerCtx = BroadcastTimeoutContext(proposerCtx, time.Nanosecond)
+	checkState(44, state.SwapStateFailed)
+}
+
+// staleStore list records as they were before other instances transitions
+type staleStore struct {
+	state.Store
+	records []state.SwapRecord
+}
+
+func (p *staleStore) List(ctx context.Context, swapState state.SwapState) ([]state.SwapRecord, error) {
+	return p.records, nil
+}
+
+// testMessaging record published swap events by subject
+type testMessaging struct {
+	sync.Mutex
+	events map[string][]common.SwapEvent
+}
+
+func newTestMessaging() *testMessaging {
+	return &testMessaging{
+		events: make(map[string][]common.SwapEvent),
+	}
+}
+
+func (p *testMessaging) Events(subject string) []common.SwapEvent {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.events[subject]
+}
+
+func (p *testMessaging) NC() bank.NC {
+	return nil
+}
+
+func (p *testMessaging) SubscribeWorkers(ctx context.Context, subject string, workerCount int, handle bank.MessageHandler) {
+}
+
+func (p *testMessaging) Subscribe(ctx context.Context, subject string, handle bank.MessageHandler) {
+}
+
+func (p *testMessaging) Publish(ctx context.Context, subject string, message *bank.Message) error {
+	var event common.SwapEvent
+	if err := bank.FromMessage(message, &event); err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.events[subject] = append(p.events[subject], event)
+	return nil
+}
+
+func (p *testMessaging) Request(ctx context.Context, subject string, message *bank.Message) (*bank.Message, error) {
+	return nil, errors.New("Not Implemented")
+}
+
+func (p *testMessaging) RequestWithTimeout(ctx context.Context, subject string, message *bank.Message, timeout time.Duration) (*bank.Message, error) {
+	return nil, errors.New("Not Implemented")
+}
+
+func TestSwapProposalBroadcast(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	store := state.SwapStoreFromContext(parties.proposerCtx)
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	created, err := CreateSwapProposal(parties.proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	accepted, err := AcceptSwapProposal(parties.acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+
+	_, err = BroadcastSwapTransaction(parties.proposerCtx, 42, created.Payload)
+	if err != common.ErrInvalidPayload {
+		t.Errorf("BroadcastSwapTransaction() proposal error = %v, want %v", err, common.ErrInvalidPayload)
+	}
+
+	signed, err := FinalizeSwapProposal(parties.proposerCtx, 42, accepted.Payload, false)
+	if err != nil {
+		t.Fatalf("FinalizeSwapProposal() error = %v", err)
+	}
+	finalized, err := signed.Payload.Finalized()
+	if err != nil || len(finalized.Tx) == 0 {
+		t.Fatalf("Payload.Finalized() = %+v, %v", finalized, err)
+	}
+	if _, ok := parties.proposer.Chain.Transaction(finalized.TxID); ok {
+		t.Errorf("Chain.Transaction() %s broadcasted before BroadcastSwapTransaction", finalized.TxID)
+	}
+	record, err := store.Get(parties.proposerCtx, 42)
+	if err != nil || record.State != state.SwapStateFinalized || record.TxID != finalized.TxID {
+		t.Errorf("Store.Get() = %+v, %v, want %v with TxID %s", record, err, state.SwapStateFinalized, finalized.TxID)
+	}
+	if reservations, _ := store.Reservations(parties.proposerCtx, ""); len(reservations) != 1 {
+		t.Errorf("Store.Reservations() = %+v, want unspents reserved until broadcast", reservations)
+	}
+
+	// other transaction can not be sent for the swap
+	other, _ := fake.DecodeTransaction(finalized.Tx)
+	other.Outputs = other.Outputs[:1]
+	forged, _ := common.EncodePayload(&common.FinalizedDocument{
+		TxID: finalized.TxID,
+		Tx:   other.Encode(),
+	}, common.PayloadJson)
+	_, err = BroadcastSwapTransaction(parties.proposerCtx, 42, forged)
+	if err != common.ErrInvalidPayload {
+		t.Errorf("BroadcastSwapTransaction() forged error = %v, want %v", err, common.ErrInvalidPayload)
+	}
+	if _, ok := parties.proposer.Chain.Transaction(other.TxID()); ok {
+		t.Errorf("Chain.Transaction() forged transaction %s broadcasted", other.TxID())
+	}
+	_, err = BroadcastSwapTransaction(parties.proposerCtx, 43, signed.Payload)
+	if err != common.ErrInvalidSwapState {
+		t.Errorf("BroadcastSwapTransaction() unknown swap error = %v, want %v", err, common.ErrInvalidSwapState)
+	}
+
+	sent, err := BroadcastSwapTransaction(parties.proposerCtx, 42, signed.Payload)
+	if err != nil {
+		t.Fatalf("BroadcastSwapTransaction() error = %v", err)
+	}
+	if sent.Payload != signed.Payload {
+		t.Errorf("BroadcastSwapTransaction() Payload = %v, want %v", sent.Payload, signed.Payload)
+	}
+	if _, ok := parties.proposer.Chain.Transaction(finalized.TxID); !ok {
+		t.Errorf("Chain.Transaction() %s not found", finalized.TxID)
+	}
+	record, err = store.Get(parties.proposerCtx, 42)
+	if err != nil || record.State != state.SwapStateBroadcast {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateBroadcast)
+	}
+	if reservations, _ := store.Reservations(parties.proposerCtx, ""); len(reservations) != 0 {
+		t.Errorf("Store.Reservations() = %+v, want none", reservations)
+	}
+
+	repeated, err := BroadcastSwapTransaction(parties.proposerCtx, 42, signed.Payload)
+	if err != nil || repeated.Payload != sent.Payload {
+		t.Errorf("BroadcastSwapTransaction() repeated = %v, %v, want %v", repeated.Payload, err, sent.Payload)
+	}
+}
+
+func TestSwapProposalEvents(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	proposerEvents := newTestMessaging()
+	acceptorEvents := newTestMessaging()
+	proposerCtx := appcontext.WithMessaging(parties.proposerCtx, proposerEvents)
+	acceptorCtx := appcontext.WithMessaging(parties.acceptorCtx, acceptorEvents)
+
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	created, err := CreateSwapProposal(proposerCtx, 42, parties.proposer.NewAddress(), proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	accepted, err := AcceptSwapProposal(acceptorCtx, 42, parties.acceptor.NewAddress(), created.Payload, common.DefaultFeeRate, created.Expiry)
+	if err != nil {
+		t.Fatalf("AcceptSwapProposal() error = %v", err)
+	}
+	finalized, err := FinalizeSwapProposal(proposerCtx, 42, accepted.Payload, true)
+	if err != nil {
+		t.Fatalf("FinalizeSwapProposal() error = %v", err)
+	}
+	document, _ := finalized.Payload.Finalized()
+
+	tests := []struct {
+		name      string
+		messaging *testMessaging
+		state     state.SwapState
+		wantTxID  string
+	}{
+		{"proposed", proposerEvents, state.SwapStateProposed, ""},
+		{"accepted", proposerEvents, state.SwapStateAccepted, document.TxID},
+		{"finalized", proposerEvents, state.SwapStateFinalized, document.TxID},
+		{"broadcast", proposerEvents, state.SwapStateBroadcast, document.TxID},
+		{"acceptor", acceptorEvents, state.SwapStateAccepted, ""},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			events := tt.messaging.Events(common.SwapEventStateSubject(string(tt.state)))
+			if len(events) != 1 {
+				t.Fatalf("Events() = %+v, want one event", events)
+			}
+			event := events[0]
+			if event.SwapID != 42 || event.State != string(tt.state) || event.TxID != tt.wantTxID {
+				t.Errorf("SwapEvent = %+v, want swap 42 %s with TxID %q", event, tt.state, tt.wantTxID)
+			}
+			if event.Proposal != proposal {
+				t.Errorf("SwapEvent Proposal = %+v, want %+v", event.Proposal, proposal)
+			}
+			if event.CreatedAt.IsZero() || event.UpdatedAt.Before(event.CreatedAt) || !event.ExpiresAt.Equal(created.Expiry) {
+				t.Errorf("SwapEvent timestamps = %v %v %v", event.CreatedAt, event.UpdatedAt, event.ExpiresAt)
+			}
+		})
+	}
+}
+
+// hangingBackend block proposals until the ctx deadline, like an unresponsive elementsd
+type hangingBackend struct {
+	*fake.Engine
+}
+
+func (p *hangingBackend) Propose(ctx context.Context, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) (common.Payload, error) {
+	<-ctx.Done()
+	return "", common.ErrBackendUnavailable
+}
+
+func TestSwapProposalTimeout(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	timeouts := common.NewRequestTimeouts(time.Minute)
+	timeouts.SetTimeout(common.SwapCreateProposalSubject, 100*time.Millisecond)
+
+	ctx := SwapBackendContext(context.Background(), &hangingBackend{Engine: parties.proposer})
+	ctx = state.SwapStoreContext(ctx, state.NewMemoryStore())
+	ctx = common.RequestTimeoutsContext(ctx, timeouts)
+
+	start := time.Now()
+	_, err := handleRequest(ctx, OnCreateSwapProposal, common.SwapProposal{
+		SwapID:  1,
+		Address: testAddress,
+		Proposal: common.ProposalInfo{
+			ProposerAsset:  assetUSDt,
+			ProposerAmount: 1000,
+			ReceiverAsset:  assetLCAD,
+			ReceiverAmount: 1400,
+		},
+	})
+	if !errors.Is(err, common.ErrRequestTimeout) {
+		t.Fatalf("OnCreateSwapProposal() error = %v, want %v", err, common.ErrRequestTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("OnCreateSwapProposal() returned after %v", elapsed)
+	}
+
+	if _, err := state.SwapStoreFromContext(ctx).Get(ctx, 1); err != state.ErrSwapNotFound {
+		t.Errorf("Store.Get() error = %v, want %v", err, state.ErrSwapNotFound)
+	}
+
+	// wallet lock must be released for the next request
+	wallet, _, _ := walletBackend(ctx)
+	locked := make(chan struct{})
+	go func() {
+		lock, err := lockWallet(ctx, wallet)
+		if err == nil {
+			lock.Unlock()
+		}
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Errorf("Wallet lock not released after timeout")
+	}
+}
+
+// lateBackend create proposals once the ctx deadline is exceeded
+type lateBackend struct {
+	*fake.Engine
+}
+
+func (p *lateBackend) Propose(ctx context.Context, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) (common.Payload, error) {
+	<-ctx.Done()
+	return p.Engine.Propose(context.Background(), address, proposal, feeRate)
+}
+
+func TestSwapProposalTimeout_LateBackend(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	timeouts := common.NewRequestTimeouts(time.Minute)
+	timeouts.SetTimeout(common.SwapCreateProposalSubject, 50*time.Millisecond)
+
+	ctx := SwapBackendContext(context.Background(), &lateBackend{Engine: parties.proposer})
+	ctx = state.SwapStoreContext(ctx, state.NewMemoryStore())
+	ctx = common.RequestTimeoutsContext(ctx, timeouts)
+
+	proposal := common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}
+
+	// created proposal is reserved and recorded after the deadline
+	created, err := CreateSwapProposal(ctx, 1, testAddress, proposal, common.DefaultFeeRate)
+	if err != nil {
+		t.Fatalf("CreateSwapProposal() error = %v", err)
+	}
+	store := state.SwapStoreFromContext(ctx)
+	if record, err := store.Get(ctx, 1); err != nil || record.State != state.SwapStateProposed {
+		t.Errorf("Store.Get() = %+v, %v, want %v", record, err, state.SwapStateProposed)
+	}
+	if reservations, _ := store.Reservations(ctx, ""); len(reservations) != 1 {
+		t.Errorf("Store.Reservations() = %+v, want proposal unspents reserved", reservations)
+	}
+
+	// repeated request get the same proposal
+	again, err := CreateSwapProposal(ctx, 1, testAddress, proposal, common.DefaultFeeRate)
+	if err != nil || again.Payload != created.Payload {
+		t.Errorf("CreateSwapProposal() repeated = %+v, %v, want %+v", again, err, created)
+	}
+}
+
+func TestSwapProposalTimeout_QueueWait(t *testing.T) {
+	t.Parallel()
+
+	parties := newSwapParties()
+	const timeout = 200 * time.Millisecond
+	timeouts := common.NewRequestTimeouts(time.Minute)
+	timeouts.SetTimeout(common.SwapCreateProposalSubject, timeout)
+
+	queue := NewWorkQueue(4)
+	ctx := SwapBackendContext(context.Background(), &hangingBackend{Engine: parties.proposer})
+	ctx = state.SwapStoreContext(ctx, state.NewMemoryStore())
+	ctx = common.RequestTimeoutsContext(ctx, timeouts)
+	ctx = WorkQueueContext(ctx, queue)
+
+	// running operation delay the request
+	wallet, _, _ := walletBackend(ctx)
+	release, err := queue.Acquire(ctx, wallet, common.SwapCreateProposalSubject)
+	if err != nil {
+		t.Fatalf("WorkQueue.Acquire() error = %v", err)
+	}
+	time.AfterFunc(3*timeout/4, release)
+
+	start := time.Now()
+	_, err = CreateSwapProposal(ctx, 1, testAddress, common.ProposalInfo{
+		ProposerAsset:  assetUSDt,
+		ProposerAmount: 1000,
+		ReceiverAsset:  assetLCAD,
+		ReceiverAmount: 1400,
+	}, common.DefaultFeeRate)
+	if err != common.ErrRequestTimeout {
+		t.Fatalf("CreateSwapProposal() error = %v, want %v", err, common.ErrRequestTimeout)
+	}
+
+	// queue wait and backend call share the operation timeout
+	if elapsed := time.Since(start); elapsed >= timeout+timeout/2 {
+		t.Errorf("CreateSwapProposal() returned after %v, want operation timeout %v", elapsed, timeout)
+	}
+}
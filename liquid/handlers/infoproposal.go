@@ -6,7 +6,6 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/condensat/bank-core"
@@ -17,8 +16,6 @@ import (
 	"github.com/condensat/bank-core/cache"
 	"github.com/condensat/bank-core/messaging"
 
-	"github.com/condensat/bank-core/utils/shellexec"
-
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,6 +24,10 @@ func InfoSwapProposal(ctx context.Context, swapID uint64, payload common.Payload
 
 	log = log.WithField("SwapID", swapID)
 
+	// operation deadline for backend calls
+	ctxOperation, cancel := operationContext(ctx, common.SwapInfoProposalSubject)
+	defer cancel()
+
 	if !payload.Valid() {
 		log.WithError(common.ErrInvalidPayload).
 			WithField("Payload", payload).
@@ -39,25 +40,24 @@ func InfoSwapProposal(ctx context.Context, swapID uint64, payload common.Payload
 		SwapID:    swapID,
 	}
 
-	ShellExecLock.Lock()
-	defer ShellExecLock.Unlock()
-
-	out, err := shellexec.Execute(ctx, LiquidSwapInfo(payload))
-	if len(out.Stdout) == 0 && err == nil {
-		err = errors.New("No Output")
+	wallet, backend, err := walletBackend(ctx)
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", wallet).
+			Error("Wallet backend not found")
+		return common.SwapProposal{}, err
 	}
+	result.Wallet = wallet
+
+	out, err := backend.Info(ctxOperation, payload)
+	err = backendError(ctxOperation, err)
 	if err != nil {
 		log.WithError(err).
-			WithFields(logrus.Fields{
-				"Stdout": out.Stdout,
-				"Stderr": out.Stderr,
-				"Code":   out.Code,
-			}).
-			Error("out")
+			Error("Swap Backend failed")
 		return result, err
 	}
 
-	result.Payload = common.Payload(out.Stdout)
+	result.Payload = out
 
 	if !result.Payload.Valid() {
 		log.WithError(common.ErrInvalidPayload).
@@ -66,6 +66,9 @@ func InfoSwapProposal(ctx context.Context, swapID uint64, payload common.Payload
 		return common.SwapProposal{}, common.ErrInvalidPayload
 	}
 
+	info := common.NewSwapInfo(payload, result.Payload)
+	result.Info = &info
+
 	log.WithField("Result", result).
 		Debug("Info Swap Proposal")
 
@@ -78,21 +81,42 @@ func OnInfoSwapProposal(ctx context.Context, subject string, message *bank.Messa
 		"Subject": subject,
 	})
 
-	var request common.SwapProposal
+	var request common.InfoProposalRequest
 	return messaging.HandleRequest(ctx, message, &request,
 		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
 			log = log.WithFields(logrus.Fields{
 				"SwapID": request.SwapID,
 			})
 
-			response, err := InfoSwapProposal(ctx, request.SwapID, request.Payload)
+			err := common.CheckSchemaVersion(request.Version)
+			if err == nil {
+				err = request.Validate(common.NetworkFromContext(ctx))
+			}
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Invalid request")
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result := errorResponse(request.SwapID, request.Wallet, err)
+				response := result.InfoProposalResponse()
+				return &response, nil
+			}
+
+			ctx = common.SwapWalletContext(ctx, request.Wallet)
+			result, err := InfoSwapProposal(ctx, request.SwapID, request.Payload)
 			if err != nil {
 				log.WithError(err).
 					Errorf("Failed to InfoSwapProposal")
-				return nil, cache.ErrInternalError
+				if legacyRequest(request.Version) {
+					return nil, cache.ErrInternalError
+				}
+				result = errorResponse(request.SwapID, request.Wallet, err)
 			}
 
 			// create & return response
+			response := result.InfoProposalResponse()
 			return &response, nil
 		})
 }
@@ -0,0 +1,60 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/condensat/bank-core/cache"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func lockKeyWallet(network common.Network, wallet string) string {
+	return fmt.Sprintf("%s.%s", network.Namespace("liquidswap.Wallet"), wallet)
+}
+
+// lockWallet prevent concurrent wallet operations across all instances.
+// All instances sharing the same elements wallet must use the same wallet name.
+// A process local mutex is used if no RedisMutex is available.
+func lockWallet(ctx context.Context, wallet string) (cache.Lock, error) {
+	mutex := cache.RedisMutexFromContext(ctx)
+	if mutex == nil {
+		return localLock(wallet), nil
+	}
+
+	return mutex.Lock(ctx, lockKeyWallet(common.NetworkFromContext(ctx), wallet), walletLockTTL(ctx))
+}
+
+var localWallets struct {
+	sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+type localWalletLock struct {
+	mutex *sync.Mutex
+}
+
+func (p *localWalletLock) Unlock() {
+	p.mutex.Unlock()
+}
+
+func localLock(wallet string) cache.Lock {
+	localWallets.Lock()
+	if localWallets.locks == nil {
+		localWallets.locks = make(map[string]*sync.Mutex)
+	}
+	mutex, ok := localWallets.locks[wallet]
+	if !ok {
+		mutex = new(sync.Mutex)
+		localWallets.locks[wallet] = mutex
+	}
+	localWallets.Unlock()
+
+	mutex.Lock()
+	return &localWalletLock{mutex: mutex}
+}
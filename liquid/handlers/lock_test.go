@@ -0,0 +1,76 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func TestLockWallet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	const wallet = "TestLockWallet"
+
+	lock, err := lockWallet(ctx, wallet)
+	if err != nil {
+		t.Fatalf("lockWallet() error = %v", err)
+	}
+
+	// other wallet must not be blocked
+	otherLock, err := lockWallet(ctx, "TestLockWalletOther")
+	if err != nil {
+		t.Fatalf("lockWallet() error = %v", err)
+	}
+	otherLock.Unlock()
+
+	locked := make(chan struct{})
+	go func() {
+		lock, _ := lockWallet(ctx, wallet)
+		close(locked)
+		lock.Unlock()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatalf("lockWallet() same wallet not locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lock.Unlock()
+	<-locked
+}
+
+func TestLockWalletContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	const wallet = "TestLockWalletContext"
+
+	lock, err := lockWallet(ctx, wallet)
+	if err != nil {
+		t.Fatalf("lockWallet() error = %v", err)
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := lockWalletContext(ctxTimeout, wallet); err != common.ErrRequestTimeout {
+		t.Errorf("lockWalletContext() error = %v, want %v", err, common.ErrRequestTimeout)
+	}
+	lock.Unlock()
+
+	// lock acquired after the deadline must be released
+	ctxLock, cancelLock := context.WithTimeout(ctx, time.Second)
+	defer cancelLock()
+	lock, err = lockWalletContext(ctxLock, wallet)
+	if err != nil {
+		t.Fatalf("lockWalletContext() error = %v", err)
+	}
+	lock.Unlock()
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/condensat/bank-core/utils"
 	"github.com/condensat/bank-swap/liquid/common"
 
 	"github.com/condensat/bank-core/utils/shellexec"
@@ -24,19 +23,10 @@ const (
 	SwapCommandFinalize = SwapCommand("finalize")
 	SwapCommandAccept   = SwapCommand("accept")
 
-	FeeRatePrecision       = 9 // BTC/Kb = 1000 / 100000000 sat/B
-	FeeRatePrecisionFormat = "%.9f"
+	DefaultElementsConf = "/etc/liquidswap/elements.conf"
 )
 
-var (
-	elementsConfFile = "/etc/liquidswap/elements.conf"
-)
-
-func SetElementsConf(elementsConf string) {
-	elementsConfFile = elementsConf
-}
-
-func liquidSwapOptions(args ...interface{}) shellexec.Options {
+func liquidSwapOptionsWithConf(elementsConf string, args ...interface{}) shellexec.Options {
 	defaultEnv := []string{
 		"LC_ALL=C.UTF-8",
 		"LANG=C.UTF-8",
@@ -44,7 +34,7 @@ func liquidSwapOptions(args ...interface{}) shellexec.Options {
 
 	var payload io.Reader
 	var finalArgs []string
-	finalArgs = append(finalArgs, "--conf-file", elementsConfFile)
+	finalArgs = append(finalArgs, "--conf-file", elementsConf)
 
 	for _, a := range args {
 		switch arg := a.(type) {
@@ -72,41 +62,46 @@ func liquidSwapOptions(args ...interface{}) shellexec.Options {
 		WithStdin(payload)
 }
 
-func LiquidSwapPropose(address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate float64) shellexec.Options {
+func LiquidSwapPropose(elementsConf string, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) shellexec.Options {
 	if feeRate < common.MinumumFeeRate {
 		feeRate = common.MinumumFeeRate
 	}
-	feeRate = utils.ToFixed(feeRate, FeeRatePrecision)
 
-	return liquidSwapOptions(
+	return liquidSwapOptionsWithConf(elementsConf,
 		"--with-address", address,
 		SwapCommandPropose,
-		"--fee-rate", fmt.Sprintf(FeeRatePrecisionFormat, feeRate),
+		"--fee-rate", feeRate.String(),
 		proposal)
 }
 
-func LiquidSwapInfo(payload common.Payload) shellexec.Options {
-	return liquidSwapOptions(SwapCommandInfo, payload)
+func LiquidSwapInfo(elementsConf string, payload common.Payload) shellexec.Options {
+	return liquidSwapOptionsWithConf(elementsConf, SwapCommandInfo, payload)
 }
 
-func LiquidSwapFinalize(payload common.Payload) shellexec.Options {
-	return liquidSwapOptions(
+// LiquidSwapFinalize sign the accepted transaction, send it if broadcast is set
+func LiquidSwapFinalize(elementsConf string, payload common.Payload, broadcast bool) shellexec.Options {
+	if !broadcast {
+		return liquidSwapOptionsWithConf(elementsConf,
+			SwapCommandFinalize,
+			payload,
+		)
+	}
+	return liquidSwapOptionsWithConf(elementsConf,
 		SwapCommandFinalize,
 		"--send",
 		payload,
 	)
 }
 
-func LiquidSwapAccept(address common.ConfidentialAddress, payload common.Payload, feeRate float64) shellexec.Options {
+func LiquidSwapAccept(elementsConf string, address common.ConfidentialAddress, payload common.Payload, feeRate common.Amount) shellexec.Options {
 	if feeRate < common.MinumumFeeRate {
 		feeRate = common.MinumumFeeRate
 	}
-	feeRate = utils.ToFixed(feeRate, FeeRatePrecision)
 
-	return liquidSwapOptions(
+	return liquidSwapOptionsWithConf(elementsConf,
 		"--with-address", address,
 		SwapCommandAccept,
-		"--fee-rate", fmt.Sprintf(FeeRatePrecisionFormat, feeRate),
+		"--fee-rate", feeRate.String(),
 		payload,
 	)
 }
@@ -15,15 +15,15 @@ func TestLiquidSwapPropose(t *testing.T) {
 
 	proposal := common.ProposalInfo{
 		ProposerAsset:  "assetP",
-		ProposerAmount: 0.1234567811111,
+		ProposerAmount: 12345678,
 		ReceiverAsset:  "assetR",
-		ReceiverAmount: 3.141592653589793,
+		ReceiverAmount: 314159265,
 	}
 
 	type args struct {
 		address  common.ConfidentialAddress
 		proposal common.ProposalInfo
-		feeRate  float64
+		feeRate  common.Amount
 	}
 	tests := []struct {
 		name      string
@@ -32,12 +32,12 @@ func TestLiquidSwapPropose(t *testing.T) {
 		wantArgs  int
 		wantStdIn bool
 	}{
-		{"propose", args{"address", proposal, 0.1337}, 2, 11, false},
+		{"propose", args{"address", proposal, 13370000}, 2, 11, false},
 	}
 	for _, tt := range tests {
 		tt := tt // capture range variable
 		t.Run(tt.name, func(t *testing.T) {
-			got := LiquidSwapPropose(tt.args.address, tt.args.proposal, tt.args.feeRate)
+			got := LiquidSwapPropose(DefaultElementsConf, tt.args.address, tt.args.proposal, tt.args.feeRate)
 
 			if got.Program != LiquidSwapCli {
 				t.Errorf("LiquidSwapPropose() wrong Program %v, want %v", got.Program, LiquidSwapCli)
@@ -75,7 +75,7 @@ func TestLiquidSwapInfo(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt // capture range variable
 		t.Run(tt.name, func(t *testing.T) {
-			got := LiquidSwapInfo(tt.args.payload)
+			got := LiquidSwapInfo(DefaultElementsConf, tt.args.payload)
 
 			if got.Program != LiquidSwapCli {
 				t.Errorf("LiquidSwapInfo() wrong Program %v, want %v", got.Program, LiquidSwapCli)
@@ -99,7 +99,8 @@ func TestLiquidSwapFinalize(t *testing.T) {
 	t.Parallel()
 
 	type args struct {
-		payload common.Payload
+		payload   common.Payload
+		broadcast bool
 	}
 	tests := []struct {
 		name      string
@@ -108,12 +109,13 @@ func TestLiquidSwapFinalize(t *testing.T) {
 		wantArgs  int
 		wantStdIn bool
 	}{
-		{"finalize", args{"payload"}, 2, 5, true},
+		{"finalize", args{"payload", true}, 2, 5, true},
+		{"finalizeNoBroadcast", args{"payload", false}, 2, 4, true},
 	}
 	for _, tt := range tests {
 		tt := tt // capture range variable
 		t.Run(tt.name, func(t *testing.T) {
-			got := LiquidSwapFinalize(tt.args.payload)
+			got := LiquidSwapFinalize(DefaultElementsConf, tt.args.payload, tt.args.broadcast)
 
 			if got.Program != LiquidSwapCli {
 				t.Errorf("LiquidSwapFinalize() wrong Program %v, want %v", got.Program, LiquidSwapCli)
@@ -139,7 +141,7 @@ func TestLiquidSwapAccept(t *testing.T) {
 	type args struct {
 		address common.ConfidentialAddress
 		payload common.Payload
-		feeRate float64
+		feeRate common.Amount
 	}
 	tests := []struct {
 		name      string
@@ -148,12 +150,12 @@ func TestLiquidSwapAccept(t *testing.T) {
 		wantArgs  int
 		wantStdIn bool
 	}{
-		{"finalize", args{"address", "payload", 0.1337}, 2, 8, true},
+		{"finalize", args{"address", "payload", 13370000}, 2, 8, true},
 	}
 	for _, tt := range tests {
 		tt := tt // capture range variable
 		t.Run(tt.name, func(t *testing.T) {
-			got := LiquidSwapAccept(tt.args.address, tt.args.payload, tt.args.feeRate)
+			got := LiquidSwapAccept(DefaultElementsConf, tt.args.address, tt.args.payload, tt.args.feeRate)
 
 			if got.Program != LiquidSwapCli {
 				t.Errorf("LiquidSwapAccept() wrong Program %v, want %v", got.Program, LiquidSwapCli)
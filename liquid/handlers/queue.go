@@ -0,0 +1,266 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/condensat/bank-core/cache"
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	WorkQueueKey = "Key.WorkQueueKey"
+
+	DefaultQueueCapacity = 32
+)
+
+// operationPriority complete pending swaps first, finalized swaps release reserved unspents
+func operationPriority(subject string) int {
+	switch subject {
+	case common.SwapFinalizeProposalSubject, common.SwapBroadcastSubject:
+		return 2
+
+	case common.SwapCancelProposalSubject, common.SwapAcceptProposalSubject:
+		return 1
+
+	default:
+		return 0
+	}
+}
+
+type queueWaiter struct {
+	priority int
+	queuedAt time.Time
+	ready    chan struct{}
+}
+
+type walletQueue struct {
+	running int
+	waiting []*queueWaiter // ordered by priority, then arrival
+
+	accepted  uint64
+	rejected  uint64
+	lastWait  time.Duration
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+func (p *walletQueue) insert(waiter *queueWaiter) {
+	index := len(p.waiting)
+	for i, other := range p.waiting {
+		if waiter.priority > other.priority {
+			index = i
+			break
+		}
+	}
+	p.waiting = append(p.waiting, nil)
+	copy(p.waiting[index+1:], p.waiting[index:])
+	p.waiting[index] = waiter
+}
+
+func (p *walletQueue) remove(waiter *queueWaiter) bool {
+	for i, other := range p.waiting {
+		if other == waiter {
+			p.waiting = append(p.waiting[:i], p.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (p *walletQueue) start(wait time.Duration) {
+	p.running++
+	p.accepted++
+	p.lastWait = wait
+	p.totalWait += wait
+	if wait > p.maxWait {
+		p.maxWait = wait
+	}
+}
+
+// WorkQueue order wallet operations by priority, one operation run per wallet like the wallet lock.
+// Requests are rejected with ErrBusy when the wallet queue is full.
+type WorkQueue struct {
+	sync.Mutex
+	capacity int
+	wallets  map[string]*walletQueue
+}
+
+func NewWorkQueue(capacity int) *WorkQueue {
+	if capacity <= 0 {
+		capacity = DefaultQueueCapacity
+	}
+	return &WorkQueue{
+		capacity: capacity,
+		wallets:  make(map[string]*walletQueue),
+	}
+}
+
+func (p *WorkQueue) wallet(wallet string) *walletQueue {
+	queue, ok := p.wallets[wallet]
+	if !ok {
+		queue = new(walletQueue)
+		p.wallets[wallet] = queue
+	}
+	return queue
+}
+
+// Acquire wait for the wallet turn, release must be called when the operation is done.
+// return ErrBusy if the queue is full, ErrRequestTimeout if ctx is done while waiting
+func (p *WorkQueue) Acquire(ctx context.Context, wallet, subject string) (func(), error) {
+	p.Lock()
+	queue := p.wallet(wallet)
+	if queue.running == 0 && len(queue.waiting) == 0 {
+		queue.start(0)
+		p.Unlock()
+		return p.releaseFunc(queue), nil
+	}
+	if len(queue.waiting) >= p.capacity {
+		queue.rejected++
+		p.Unlock()
+		return nil, common.ErrBusy
+	}
+
+	waiter := queueWaiter{
+		priority: operationPriority(subject),
+		queuedAt: time.Now(),
+		ready:    make(chan struct{}),
+	}
+	queue.insert(&waiter)
+	p.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return p.releaseFunc(queue), nil
+
+	case <-ctx.Done():
+		p.Lock()
+		defer p.Unlock()
+		if queue.remove(&waiter) {
+			queue.rejected++
+			return nil, common.ErrRequestTimeout
+		}
+		// turn granted while ctx was done
+		return p.releaseFunc(queue), nil
+	}
+}
+
+func (p *WorkQueue) releaseFunc(queue *walletQueue) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.Lock()
+			defer p.Unlock()
+
+			queue.running--
+			if queue.running > 0 || len(queue.waiting) == 0 {
+				return
+			}
+			waiter := queue.waiting[0]
+			queue.waiting = queue.waiting[1:]
+			queue.start(time.Since(waiter.queuedAt))
+			close(waiter.ready)
+		})
+	}
+}
+
+// Status return queues state sorted by wallet
+func (p *WorkQueue) Status() []common.QueueStatus {
+	p.Lock()
+	defer p.Unlock()
+
+	var result []common.QueueStatus
+	for wallet, queue := range p.wallets {
+		status := common.QueueStatus{
+			Wallet:   wallet,
+			Depth:    len(queue.waiting),
+			Running:  queue.running,
+			Capacity: p.capacity,
+			Accepted: queue.accepted,
+			Rejected: queue.rejected,
+			LastWait: queue.lastWait,
+			MaxWait:  queue.maxWait,
+		}
+		if queue.accepted > 0 {
+			status.AvgWait = queue.totalWait / time.Duration(queue.accepted)
+		}
+		result = append(result, status)
+	}
+	common.SortQueueStatus(result)
+	return result
+}
+
+func WorkQueueContext(ctx context.Context, queue *WorkQueue) context.Context {
+	return context.WithValue(ctx, WorkQueueKey, queue)
+}
+
+// WorkQueueFromContext return nil if no queue is set, operations are not queued
+func WorkQueueFromContext(ctx context.Context) *WorkQueue {
+	switch queue := ctx.Value(WorkQueueKey).(type) {
+	case *WorkQueue:
+		return queue
+
+	default:
+		return nil
+	}
+}
+
+type queuedLock struct {
+	lock    cache.Lock
+	release func()
+}
+
+func (p *queuedLock) Unlock() {
+	p.lock.Unlock()
+	p.release()
+}
+
+// queueWallet wait for the operation turn in the work queue, then lock the wallet.
+// Queue wait and wallet lock are limited by the ctx operation deadline.
+// return ErrBusy if the queue is full, ErrRequestTimeout if the queue turn or the wallet lock
+// is not acquired before the deadline, or ErrWalletBusy if the wallet lock failed
+func queueWallet(ctx context.Context, wallet, subject string) (cache.Lock, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.queueWallet")
+	log = log.WithFields(logrus.Fields{
+		"Wallet":  wallet,
+		"Subject": subject,
+	})
+
+	release := func() {}
+	if queue := WorkQueueFromContext(ctx); queue != nil {
+		var err error
+		release, err = queue.Acquire(ctx, wallet, subject)
+		if err != nil {
+			log.WithError(err).
+				Warning("Work queue rejected request")
+			return nil, err
+		}
+	}
+
+	lock, err := lockWalletContext(ctx, wallet)
+	if err == common.ErrRequestTimeout {
+		release()
+		log.WithError(err).
+			Error("Wallet lock timeout")
+		return nil, err
+	}
+	if err != nil {
+		release()
+		log.WithError(err).
+			Error("Failed to lock wallet")
+		return nil, common.ErrWalletBusy
+	}
+	return &queuedLock{
+		lock:    lock,
+		release: release,
+	}, nil
+}
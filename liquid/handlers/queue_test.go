@@ -0,0 +1,199 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func waitQueueDepth(t *testing.T, queue *WorkQueue, depth int) {
+	for i := 0; i < 100; i++ {
+		status := queue.Status()
+		if len(status) == 1 && status[0].Depth == depth {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("WorkQueue depth not reached: %d", depth)
+}
+
+func TestWorkQueue_Priority(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	queue := NewWorkQueue(4)
+
+	release, err := queue.Acquire(ctx, "wallet", common.SwapCreateProposalSubject)
+	if err != nil {
+		t.Fatalf("WorkQueue.Acquire() error = %v", err)
+	}
+
+	started := make(chan string, 3)
+	subjects := []string{
+		common.SwapCreateProposalSubject,
+		common.SwapFinalizeProposalSubject,
+		common.SwapAcceptProposalSubject,
+	}
+	for i, subject := range subjects {
+		subject := subject
+		go func() {
+			release, err := queue.Acquire(ctx, "wallet", subject)
+			if err != nil {
+				started <- err.Error()
+				return
+			}
+			started <- subject
+			release()
+		}()
+		waitQueueDepth(t, queue, i+1)
+	}
+
+	release()
+
+	want := []string{
+		common.SwapFinalizeProposalSubject,
+		common.SwapAcceptProposalSubject,
+		common.SwapCreateProposalSubject,
+	}
+	for _, subject := range want {
+		if got := <-started; got != subject {
+			t.Errorf("WorkQueue order = %v, want %v", got, subject)
+		}
+	}
+
+	status := queue.Status()
+	if len(status) != 1 || status[0].Accepted != 4 || status[0].Depth != 0 || status[0].Running != 0 {
+		t.Errorf("WorkQueue.Status() = %+v", status)
+	}
+	if status[0].MaxWait <= 0 || status[0].AvgWait <= 0 {
+		t.Errorf("WorkQueue.Status() wait times not reported %+v", status[0])
+	}
+}
+
+func TestWorkQueue_Busy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	queue := NewWorkQueue(1)
+
+	release, err := queue.Acquire(ctx, "wallet", common.SwapCreateProposalSubject)
+	if err != nil {
+		t.Fatalf("WorkQueue.Acquire() error = %v", err)
+	}
+
+	waited := make(chan error)
+	go func() {
+		release, err := queue.Acquire(ctx, "wallet", common.SwapFinalizeProposalSubject)
+		if err == nil {
+			release()
+		}
+		waited <- err
+	}()
+	waitQueueDepth(t, queue, 1)
+
+	// queue full, fast fail
+	if _, err := queue.Acquire(ctx, "wallet", common.SwapFinalizeProposalSubject); err != common.ErrBusy {
+		t.Errorf("WorkQueue.Acquire() error = %v, want %v", err, common.ErrBusy)
+	}
+
+	release()
+	if err := <-waited; err != nil {
+		t.Errorf("WorkQueue.Acquire() queued error = %v", err)
+	}
+
+	status := queue.Status()
+	if len(status) != 1 || status[0].Rejected != 1 || status[0].Accepted != 2 || status[0].Depth != 0 {
+		t.Errorf("WorkQueue.Status() = %+v", status)
+	}
+}
+
+func TestWorkQueue_WaitTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	queue := NewWorkQueue(4)
+
+	release, err := queue.Acquire(ctx, "wallet", common.SwapCreateProposalSubject)
+	if err != nil {
+		t.Fatalf("WorkQueue.Acquire() error = %v", err)
+	}
+	defer release()
+
+	// other wallet queue is independent
+	other, err := queue.Acquire(ctx, "other", common.SwapCreateProposalSubject)
+	if err != nil {
+		t.Fatalf("WorkQueue.Acquire() other wallet error = %v", err)
+	}
+	other()
+
+	// deadline reached while waiting is a timeout, not a full queue
+	ctxWait, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := queue.Acquire(ctxWait, "wallet", common.SwapFinalizeProposalSubject); err != common.ErrRequestTimeout {
+		t.Errorf("WorkQueue.Acquire() error = %v, want %v", err, common.ErrRequestTimeout)
+	}
+
+	status := queue.Status()
+	if len(status) != 2 || status[0].Wallet != "other" || status[1].Wallet != "wallet" {
+		t.Fatalf("WorkQueue.Status() = %+v", status)
+	}
+	if status[1].Rejected != 1 || status[1].Depth != 0 || status[1].Running != 1 {
+		t.Errorf("WorkQueue.Status() = %+v", status[1])
+	}
+}
+
+func TestWorkQueue_WaitCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	queue := NewWorkQueue(4)
+
+	release, err := queue.Acquire(ctx, "wallet", common.SwapCreateProposalSubject)
+	if err != nil {
+		t.Fatalf("WorkQueue.Acquire() error = %v", err)
+	}
+	defer release()
+
+	ctxWait, cancel := context.WithCancel(ctx)
+	waited := make(chan error)
+	go func() {
+		_, err := queue.Acquire(ctxWait, "wallet", common.SwapFinalizeProposalSubject)
+		waited <- err
+	}()
+	waitQueueDepth(t, queue, 1)
+
+	// request cancelled while waiting
+	cancel()
+	if err := <-waited; err != common.ErrRequestTimeout {
+		t.Errorf("WorkQueue.Acquire() error = %v, want %v", err, common.ErrRequestTimeout)
+	}
+
+	status := queue.Status()
+	if len(status) != 1 || status[0].Rejected != 1 || status[0].Depth != 0 || status[0].Running != 1 {
+		t.Errorf("WorkQueue.Status() = %+v", status)
+	}
+}
+
+func TestSwapQueueStatus_Hostname(t *testing.T) {
+	t.Parallel()
+
+	ctx := WorkQueueContext(context.Background(), NewWorkQueue(4))
+	status, err := SwapQueueStatus(ctx)
+	if err != nil {
+		t.Fatalf("SwapQueueStatus() error = %v", err)
+	}
+	if len(status.Hostname) == 0 {
+		t.Errorf("SwapQueueStatus() missing Hostname %+v", status)
+	}
+
+	// queue status subscription require a nats connection
+	if err := SubscribeSwapQueueStatus(context.Background(), common.SwapQueueStatusSubject); err != ErrSubscribeFailed {
+		t.Errorf("SubscribeSwapQueueStatus() error = %v, want %v", err, ErrSubscribeFailed)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/condensat/bank-core"
+	"github.com/condensat/bank-core/appcontext"
+	"github.com/condensat/bank-core/logger"
+	"github.com/condensat/bank-core/utils"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/condensat/bank-core/messaging"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrSubscribeFailed = errors.New("Subscribe Failed")
+)
+
+// SwapQueueStatus return work queues depth and wait times of this instance for monitoring
+func SwapQueueStatus(ctx context.Context) (common.SwapQueueStatus, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.SwapQueueStatus")
+
+	result := common.SwapQueueStatus{
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+		Hostname:  utils.Hostname(),
+	}
+	if queue := WorkQueueFromContext(ctx); queue != nil {
+		result.Queues = queue.Status()
+	}
+
+	log.WithField("Queues", len(result.Queues)).
+		Debug("Swap Queue Status")
+
+	return result, nil
+}
+
+func OnSwapQueueStatus(ctx context.Context, subject string, message *bank.Message) (*bank.Message, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.OnSwapQueueStatus")
+	log = log.WithFields(logrus.Fields{
+		"Subject": subject,
+	})
+
+	var request common.SwapQueueStatus
+	return messaging.HandleRequest(ctx, message, &request,
+		func(ctx context.Context, _ bank.BankObject) (bank.BankObject, error) {
+			err := common.CheckSchemaVersion(request.Version)
+			if err != nil {
+				log.WithError(err).
+					WithField("Version", request.Version).
+					Error("Unsupported request version")
+				return &common.SwapQueueStatus{
+					Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+					Hostname:  utils.Hostname(),
+					Error:     common.NewSwapError(err),
+				}, nil
+			}
+
+			response, err := SwapQueueStatus(ctx)
+			if err != nil {
+				log.WithError(err).
+					Errorf("Failed to SwapQueueStatus")
+				return &common.SwapQueueStatus{
+					Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+					Hostname:  utils.Hostname(),
+					Error:     common.NewSwapError(err),
+				}, nil
+			}
+
+			// create & return response
+			return &response, nil
+		})
+}
+
+// SubscribeSwapQueueStatus reply to queue status requests on subject.
+// Work queues are per instance, bank-core Subscribe is a queue subscription
+// and would report the queues of a single instance.
+func SubscribeSwapQueueStatus(ctx context.Context, subject string) error {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.SubscribeSwapQueueStatus")
+	log = log.WithField("Subject", subject)
+
+	messaging := appcontext.Messaging(ctx)
+	if messaging == nil {
+		return ErrSubscribeFailed
+	}
+	nc, ok := messaging.NC().(*nats.Conn)
+	if !ok || nc == nil {
+		return ErrSubscribeFailed
+	}
+
+	_, err := nc.Subscribe(subject, func(msg *nats.Msg) {
+		request := new(bank.Message)
+		err := request.Decode(msg.Data)
+		if err != nil {
+			log.WithError(err).
+				Error("Invalid request")
+			return
+		}
+
+		response, err := OnSwapQueueStatus(ctx, msg.Subject, request)
+		if response == nil {
+			response = bank.NewMessage()
+			response.Error = fmt.Sprintf("%s", err)
+		}
+		data, err := response.Encode()
+		if err == nil {
+			err = msg.Respond(data)
+		}
+		if err != nil {
+			log.WithError(err).
+				Error("Failed to send response")
+		}
+	})
+	return err
+}
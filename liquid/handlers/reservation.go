@@ -0,0 +1,323 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	BroadcastWindowKey = "Key.BroadcastWindowKey"
+
+	DefaultReservationInterval = 30 * time.Second
+	// DefaultBroadcastWindow is the delay to broadcast a swap finalized without broadcast
+	DefaultBroadcastWindow = time.Hour
+)
+
+// BroadcastWindowContext set the delay to broadcast a swap finalized without broadcast
+func BroadcastWindowContext(ctx context.Context, window time.Duration) context.Context {
+	return context.WithValue(ctx, BroadcastWindowKey, window)
+}
+
+// BroadcastWindowFromContext return DefaultBroadcastWindow if not set
+func BroadcastWindowFromContext(ctx context.Context) time.Duration {
+	if window, ok := ctx.Value(BroadcastWindowKey).(time.Duration); ok && window > 0 {
+		return window
+	}
+	return DefaultBroadcastWindow
+}
+
+// reserveUnspents record the proposal inputs and lock them in the wallet,
+// concurrent proposals can not select the same unspents
+func reserveUnspents(ctx context.Context, backend common.SwapBackend, swapID uint64, wallet string, payload common.Payload, expiresAt time.Time) error {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return nil
+	}
+
+	reservation, err := state.NewReservation(swapID, wallet, payload, expiresAt)
+	if err != nil {
+		return err
+	}
+	err = store.Reserve(ctx, reservation)
+	if err != nil {
+		return err
+	}
+
+	err = backend.LockUnspents(ctx, reservation.Outpoints())
+	if err != nil {
+		if _, errRelease := store.Release(ctx, swapID); errRelease != nil {
+			logger.Logger(ctx).WithError(errRelease).
+				WithField("SwapID", swapID).
+				Warning("Failed to release reservation")
+		}
+		return err
+	}
+	return nil
+}
+
+// releaseUnspents remove the swap reservation and unlock wallet unspents
+// return false if swap has no reservation
+func releaseUnspents(ctx context.Context, swapID uint64) (state.Reservation, bool) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.releaseUnspents")
+	log = log.WithField("SwapID", swapID)
+
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return state.Reservation{}, false
+	}
+
+	reservation, err := store.Release(ctx, swapID)
+	if err != nil {
+		if err != state.ErrReservationNotFound {
+			log.WithError(err).
+				Warning("Failed to release reservation")
+		}
+		return state.Reservation{}, false
+	}
+
+	_, backend, err := walletBackend(common.SwapWalletContext(ctx, reservation.Wallet))
+	if err == nil {
+		err = backend.UnlockUnspents(ctx, reservation.Outpoints())
+	}
+	if err != nil {
+		log.WithError(err).
+			WithField("Wallet", reservation.Wallet).
+			Warning("Failed to unlock unspents")
+	}
+	return reservation, true
+}
+
+// swapTerms return the recorded proposal and its reserved unspents
+// without swap store, terms are empty and no wallet unspent can be spent
+func swapTerms(ctx context.Context, swapID uint64, wallet string) (common.SwapTerms, error) {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return common.SwapTerms{}, nil
+	}
+
+	record, err := store.Get(ctx, swapID)
+	if err != nil {
+		return common.SwapTerms{}, err
+	}
+	reservations, err := store.Reservations(ctx, wallet)
+	if err != nil {
+		return common.SwapTerms{}, err
+	}
+
+	result := common.SwapTerms{
+		Proposal: record.Proposal,
+	}
+	for _, reservation := range reservations {
+		if reservation.SwapID != swapID {
+			continue
+		}
+		for _, utxo := range reservation.Unspents {
+			result.Inputs = append(result.Inputs, common.ReservedInput{
+				Outpoint: utxo.Outpoint,
+				Asset:    utxo.Asset,
+				Amount:   utxo.Amount,
+			})
+		}
+	}
+	return result, nil
+}
+
+// restoreUnspents reserve again unspents released by releaseUnspents
+func restoreUnspents(ctx context.Context, reservation state.Reservation) {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return
+	}
+
+	err := store.Reserve(ctx, reservation)
+	if err == nil {
+		var backend common.SwapBackend
+		_, backend, err = walletBackend(common.SwapWalletContext(ctx, reservation.Wallet))
+		if err == nil {
+			err = backend.LockUnspents(ctx, reservation.Outpoints())
+		}
+	}
+	if err != nil {
+		logger.Logger(ctx).WithError(err).
+			WithField("SwapID", reservation.SwapID).
+			Warning("Failed to restore reservation")
+	}
+}
+
+// RestoreReservations lock reserved unspents in wallets, elementsd locks are lost on restart
+func RestoreReservations(ctx context.Context) error {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.RestoreReservations")
+
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return nil
+	}
+
+	reservations, err := store.Reservations(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		_, backend, err := walletBackend(common.SwapWalletContext(ctx, reservation.Wallet))
+		if err == nil {
+			err = backend.LockUnspents(ctx, reservation.Outpoints())
+		}
+		if err != nil {
+			log.WithError(err).
+				WithFields(logrus.Fields{
+					"SwapID": reservation.SwapID,
+					"Wallet": reservation.Wallet,
+				}).Warning("Failed to lock reserved unspents")
+		}
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations release unspents of expired proposals and record swaps as expired.
+// Swaps finalized without broadcast are failed once the broadcast window is expired
+// and their inputs are spent back.
+func ReleaseExpiredReservations(ctx context.Context) error {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.ReleaseExpiredReservations")
+
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return nil
+	}
+
+	reservations, err := store.Reservations(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		if !reservation.Expired() {
+			continue
+		}
+
+		// do not release while a request is running for the wallet
+		lock, err := lockWallet(ctx, reservation.Wallet)
+		if err != nil {
+			log.WithError(err).
+				WithField("Wallet", reservation.Wallet).
+				Warning("Failed to lock wallet")
+			continue
+		}
+		record, err := store.Get(ctx, reservation.SwapID)
+		if err != nil && err != state.ErrSwapNotFound {
+			lock.Unlock()
+			continue
+		}
+		if record.State == state.SwapStateFinalized {
+			// finalized transaction wait for broadcast until the broadcast window is expired
+			if time.Since(record.UpdatedAt) < BroadcastWindowFromContext(ctx) {
+				lock.Unlock()
+				continue
+			}
+			err = invalidateFinalizedSwap(ctx, store, record)
+			lock.Unlock()
+			if err != nil {
+				log.WithError(err).
+					WithField("SwapID", reservation.SwapID).
+					Error("Failed to invalidate finalized swap")
+			}
+			continue
+		}
+		_ = checkExpiry(ctx, reservation.SwapID, reservation.ExpiresAt)
+		releaseUnspents(ctx, reservation.SwapID)
+		lock.Unlock()
+
+		log.WithFields(logrus.Fields{
+			"SwapID":   reservation.SwapID,
+			"Wallet":   reservation.Wallet,
+			"Unspents": len(reservation.Unspents),
+		}).Info("Expired reservation released")
+	}
+	return nil
+}
+
+// invalidateFinalizedSwap double spend the proposal inputs of a swap finalized without broadcast,
+// the signed transaction can still be sent by the acceptor until one of its inputs is spent.
+// Swap is recorded as broadcast if the swap transaction was sent meanwhile.
+// On error, the swap stay finalized and its unspents reserved.
+func invalidateFinalizedSwap(ctx context.Context, store state.Store, record state.SwapRecord) error {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.invalidateFinalizedSwap")
+	log = log.WithField("SwapID", record.SwapID)
+
+	ctx = common.SwapWalletContext(ctx, record.Wallet)
+	_, backend, err := walletBackend(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctxOperation, cancel := operationContext(ctx, common.SwapCancelProposalSubject)
+	defer cancel()
+
+	// reserved unspents must be unlocked to be spent back
+	reservation, reserved := releaseUnspents(ctx, record.SwapID)
+	if !reserved {
+		return state.ErrReservationNotFound
+	}
+
+	feeRate := common.NetworkFromContext(ctx).Params().DefaultFeeRate
+	out, err := backend.Cancel(ctxOperation, reservation.Payload, feeRate)
+	err = backendError(ctxOperation, err)
+	if err == common.ErrInvalidSwapState {
+		// inputs are already spent, by the swap transaction or by another one
+		if _, errTx := backend.Confirmations(ctxOperation, record.TxID); errTx == nil {
+			_, err = transitionSwap(ctx, store, record.SwapID, state.SwapStateBroadcast, nil, nil)
+			return err
+		}
+		_, err = transitionSwap(ctx, store, record.SwapID, state.SwapStateFailed, nil, common.ErrBroadcastExpired)
+		return err
+	}
+	if err == nil {
+		var cancelled common.CancelledDocument
+		cancelled, err = out.Cancelled()
+		if err == nil {
+			log.WithField("CancelTxID", cancelled.CancelTxID).
+				Info("Finalized swap inputs spent back")
+		}
+	}
+	if err != nil {
+		restoreUnspents(ctx, reservation)
+		return err
+	}
+
+	_, err = transitionSwap(ctx, store, record.SwapID, state.SwapStateFailed, nil, common.ErrBroadcastExpired)
+	return err
+}
+
+// WatchReservations restore wallet locks, then release expired reservations every interval until ctx is done
+func WatchReservations(ctx context.Context, interval time.Duration) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.handler.WatchReservations")
+
+	if err := RestoreReservations(ctx); err != nil {
+		log.WithError(err).
+			Error("Failed to restore reservations")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := ReleaseExpiredReservations(ctx); err != nil {
+				log.WithError(err).
+					Error("Failed to release expired reservations")
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+	"github.com/condensat/bank-swap/liquid/state"
+)
+
+// swapState return current swap state, SwapStateNone if swap is unknown or no store is available
+func swapState(ctx context.Context, swapID uint64) (state.SwapState, error) {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return state.SwapStateNone, nil
+	}
+
+	record, err := store.Get(ctx, swapID)
+	if err == state.ErrSwapNotFound {
+		return state.SwapStateNone, nil
+	}
+	if err != nil {
+		return state.SwapStateNone, err
+	}
+	return record.State, nil
+}
+
+// checkSwapTransition return ErrInvalidTransition if swap can not change to state
+func checkSwapTransition(ctx context.Context, swapID uint64, to state.SwapState) error {
+	if state.SwapStoreFromContext(ctx) == nil {
+		return nil
+	}
+
+	current, err := swapState(ctx, swapID)
+	if err != nil {
+		return err
+	}
+	if !current.CanTransition(to) {
+		return state.ErrInvalidTransition
+	}
+	return nil
+}
+
+// recordSwapTransitions apply all transitions in order, an event is published for each state
+func recordSwapTransitions(ctx context.Context, swapID uint64, update state.UpdateFunc, transitions ...state.SwapState) error {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return nil
+	}
+
+	for _, to := range transitions {
+		_, err := transitionSwap(ctx, store, swapID, to, update, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestFingerprint identify an operation request by its arguments
+func requestFingerprint(args ...interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// previousResult return the result of an already completed operation
+// returns ErrRequestConflict if the operation was completed for another request
+func previousResult(ctx context.Context, swapID uint64, operation state.Operation, fingerprint string) (common.SwapProposal, bool, error) {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return common.SwapProposal{}, false, nil
+	}
+
+	previous, err := store.Result(ctx, swapID, operation)
+	if err != nil {
+		if err != state.ErrResultNotFound {
+			logger.Logger(ctx).WithError(err).
+				WithField("SwapID", swapID).
+				Warning("Failed to get previous result")
+		}
+		return common.SwapProposal{}, false, nil
+	}
+	if previous.Fingerprint != fingerprint {
+		return common.SwapProposal{}, false, common.ErrRequestConflict
+	}
+	return previous.Result, true, nil
+}
+
+// saveResult keep result for repeated requests with the same fingerprint
+func saveResult(ctx context.Context, swapID uint64, operation state.Operation, fingerprint string, result common.SwapProposal) {
+	store := state.SwapStoreFromContext(ctx)
+	if store == nil {
+		return
+	}
+
+	err := store.SaveResult(ctx, swapID, operation, state.OperationResult{
+		Fingerprint: fingerprint,
+		Result:      result,
+	})
+	if err != nil {
+		logger.Logger(ctx).WithError(err).
+			WithField("SwapID", swapID).
+			Warning("Failed to save result")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/condensat/bank-core/cache"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+// operationContext return ctx with the operation timeout, created once at handler entry.
+// Queue wait, wallet lock and backend calls share the deadline, so the handler reply
+// is sent before the client request timeout.
+// Backends must stop on ctx deadline, cli subprocess are killed.
+func operationContext(ctx context.Context, subject string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, common.RequestTimeoutsFromContext(ctx).Timeout(subject))
+}
+
+type walletLockResult struct {
+	lock cache.Lock
+	err  error
+}
+
+// lockWalletContext return ErrRequestTimeout if the wallet is not locked before ctx deadline.
+// A lock acquired after the deadline is released.
+func lockWalletContext(ctx context.Context, wallet string) (cache.Lock, error) {
+	done := make(chan walletLockResult, 1)
+	go func() {
+		lock, err := lockWallet(ctx, wallet)
+		done <- walletLockResult{lock: lock, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.lock, result.err
+
+	case <-ctx.Done():
+		go func() {
+			if result := <-done; result.err == nil {
+				result.lock.Unlock()
+			}
+		}()
+		return nil, common.ErrRequestTimeout
+	}
+}
+
+// backendError return ErrRequestTimeout if the operation deadline is exceeded
+func backendError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return common.ErrRequestTimeout
+	}
+	return err
+}
+
+// walletLockTTL must be longer than operations timeouts
+func walletLockTTL(ctx context.Context) time.Duration {
+	ttl := common.RequestTimeoutsFromContext(ctx).Max() + common.RequestTimeoutMargin
+	if ttl < cache.DefaultLockTTL {
+		ttl = cache.DefaultLockTTL
+	}
+	return ttl
+}
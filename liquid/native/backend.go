@@ -0,0 +1,616 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrProposalMismatch      = errors.New("Proposal Transaction Mismatch")
+	ErrIncompleteTransaction = errors.New("Incomplete Transaction")
+)
+
+// Backend implements the liquidswap protocol with elementsd JSON-RPC.
+// The proposer build an unblinded transaction with its inputs and requested output.
+// The acceptor add its inputs, outputs and fee, then blind and sign.
+// The proposer finalize by signing its inputs and broadcasting the transaction.
+type Backend struct {
+	rpc *RpcClient
+}
+
+func NewBackend(elementsConf string) (*Backend, error) {
+	options, err := LoadElementsConf(elementsConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBackendWithRpc(NewRpcClient(options)), nil
+}
+
+func NewBackendWithRpc(rpc *RpcClient) *Backend {
+	return &Backend{
+		rpc: rpc,
+	}
+}
+
+func (p *Backend) Propose(ctx context.Context, address common.ConfidentialAddress, proposal common.ProposalInfo, feeRate common.Amount) (common.Payload, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.native.Propose")
+
+	proposerAsset := string(proposal.ProposerAsset)
+	proposerAmount := proposal.ProposerAmount
+
+	unspents, err := listUnspent(ctx, p.rpc, proposerAsset)
+	if err != nil {
+		return "", err
+	}
+	selected, total, err := selectCoins(unspents, proposerAmount)
+	if err != nil {
+		return "", err
+	}
+
+	// proposer receive the requested asset, fee are paid by the acceptor
+	outputs := []TxOutput{
+		{Address: string(address), Amount: proposal.ReceiverAmount, Asset: string(proposal.ReceiverAsset)},
+	}
+	if change := total - proposerAmount; change > 0 {
+		changeAddress, err := getRawChangeAddress(ctx, p.rpc)
+		if err != nil {
+			return "", err
+		}
+		outputs = append(outputs, TxOutput{Address: changeAddress, Amount: change, Asset: proposerAsset})
+	}
+
+	txHex, err := createRawTransaction(ctx, p.rpc, txInputs(selected), outputs)
+	if err != nil {
+		return "", err
+	}
+
+	log.WithFields(logrus.Fields{
+		"Inputs":  len(selected),
+		"Outputs": len(outputs),
+	}).Debug("Proposal created")
+
+	return common.EncodePayload(&common.ProposalDocument{
+		ProtocolVersion: common.ProtocolVersion,
+		Tx:              txHex,
+		AssetP:          proposal.ProposerAsset,
+		AmountP:         proposal.ProposerAmount,
+		AssetR:          proposal.ReceiverAsset,
+		AmountR:         proposal.ReceiverAmount,
+		Inputs:          inputBlinders(selected),
+	}, common.PayloadBase64)
+}
+
+func (p *Backend) Info(ctx context.Context, payload common.Payload) (common.Payload, error) {
+	if proposal, err := payload.Proposal(); err == nil {
+		tx, err := decodeTransaction(proposal.Tx)
+		if err != nil {
+			return "", err
+		}
+		return common.EncodePayload(&common.SwapInfo{
+			Stage:           common.PayloadStageProposal,
+			ProtocolVersion: proposal.ProtocolVersion,
+			ProposerAsset:   proposal.AssetP,
+			ProposerAmount:  proposal.AmountP,
+			ReceiverAsset:   proposal.AssetR,
+			ReceiverAmount:  proposal.AmountR,
+			Inputs:          len(tx.Inputs),
+			Outputs:         len(tx.Outputs),
+		}, common.PayloadJson)
+	}
+
+	accepted, err := payload.Accepted()
+	if err != nil {
+		return "", err
+	}
+	tx, err := decodeRawTransaction(ctx, p.rpc, accepted.Tx)
+	if err != nil {
+		return "", err
+	}
+	return common.EncodePayload(&common.SwapInfo{
+		Stage:           common.PayloadStageAccepted,
+		ProtocolVersion: accepted.ProtocolVersion,
+		TxID:            tx.TxID,
+		Fee:             accepted.Fee,
+		Locked:          true,
+		Inputs:          len(tx.Vin),
+		Outputs:         len(tx.Vout),
+	}, common.PayloadJson)
+}
+
+func (p *Backend) Accept(ctx context.Context, address common.ConfidentialAddress, payload common.Payload, feeRate common.Amount) (common.Payload, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.native.Accept")
+
+	proposal, err := payload.Proposal()
+	if err != nil {
+		return "", err
+	}
+	proposerTx, err := decodeTransaction(proposal.Tx)
+	if err != nil {
+		return "", err
+	}
+	if err := checkProposal(proposal, proposerTx); err != nil {
+		return "", err
+	}
+
+	policy, err := policyAsset(ctx, p.rpc)
+	if err != nil {
+		return "", err
+	}
+
+	proposerAsset := string(proposal.AssetP)
+	receiverAsset := string(proposal.AssetR)
+	receiverAmount := proposal.AmountR
+	fundings := map[string]common.Amount{receiverAsset: receiverAmount}
+
+	// proposer outputs, acceptor requested output and changes
+	blindedOutputs := len(proposerTx.Outputs) + 2
+	if receiverAsset != policy {
+		blindedOutputs++
+	}
+
+	var selected []Unspent
+	var totals map[string]common.Amount
+	// select again until the selected inputs cover their own fee,
+	// fee increase on each selection so the wallet funds end the loop
+	fee := estimateFee(len(proposerTx.Inputs)+1, blindedOutputs, feeRate)
+	for {
+		fundings[policy] = fee
+		if receiverAsset == policy {
+			fundings[policy] = receiverAmount + fee
+		}
+
+		selected, totals, err = p.selectFundings(ctx, fundings)
+		if err != nil {
+			return "", err
+		}
+		needed := estimateFee(len(proposerTx.Inputs)+len(selected), blindedOutputs, feeRate)
+		if needed <= fee {
+			break
+		}
+		fee = needed
+	}
+
+	outputs := []TxOutput{
+		{Address: string(address), Amount: proposal.AmountP, Asset: proposerAsset},
+	}
+	for _, asset := range fundingAssets(fundings) {
+		change := totals[asset] - fundings[asset]
+		if change <= 0 {
+			continue
+		}
+		changeAddress, err := getRawChangeAddress(ctx, p.rpc)
+		if err != nil {
+			return "", err
+		}
+		outputs = append(outputs, TxOutput{Address: changeAddress, Amount: change, Asset: asset})
+	}
+	outputs = append(outputs, TxOutput{Address: FeeAddress, Amount: fee, Asset: policy})
+
+	acceptorHex, err := createRawTransaction(ctx, p.rpc, txInputs(selected), outputs)
+	if err != nil {
+		return "", err
+	}
+	acceptorTx, err := decodeTransaction(acceptorHex)
+	if err != nil {
+		return "", err
+	}
+	tx := mergeTransactions(proposerTx, acceptorTx)
+
+	// blinders must follow transaction inputs order
+	var blinders []Unspent
+	for _, input := range proposal.Inputs {
+		blinders = append(blinders, Unspent{
+			TxID:          input.TxID,
+			Vout:          input.Vout,
+			Amount:        input.Amount,
+			Asset:         input.Asset,
+			AmountBlinder: input.AmountBlinder,
+			AssetBlinder:  input.AssetBlinder,
+		})
+	}
+	blinders = append(blinders, selected...)
+
+	blinded, err := rawBlindRawTransaction(ctx, p.rpc, tx.Encode(), blinders)
+	if err != nil {
+		return "", err
+	}
+	signed, err := signRawTransactionWithWallet(ctx, p.rpc, blinded)
+	if err != nil {
+		return "", err
+	}
+
+	log.WithFields(logrus.Fields{
+		"Inputs":  len(tx.Inputs),
+		"Outputs": len(tx.Outputs),
+		"Fee":     fee,
+	}).Debug("Proposal accepted")
+
+	return common.EncodePayload(&common.AcceptedDocument{
+		ProtocolVersion: common.ProtocolVersion,
+		Tx:              signed.Hex,
+		Fee:             fee,
+	}, common.PayloadBase64)
+}
+
+func (p *Backend) Finalize(ctx context.Context, payload common.Payload, terms common.SwapTerms, broadcast bool) (common.Payload, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.native.Finalize")
+
+	accepted, err := payload.Accepted()
+	if err != nil {
+		return "", err
+	}
+	// the wallet sign all its inputs, check before signing
+	err = p.CheckTerms(ctx, accepted.Tx, terms)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := signRawTransactionWithWallet(ctx, p.rpc, accepted.Tx)
+	if err != nil {
+		return "", err
+	}
+	if !signed.Complete {
+		return "", ErrIncompleteTransaction
+	}
+
+	var txID string
+	if broadcast {
+		txID, err = sendRawTransaction(ctx, p.rpc, signed.Hex)
+	} else {
+		txID, err = p.TxID(ctx, signed.Hex)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.WithFields(logrus.Fields{
+		"TxID":      txID,
+		"Broadcast": broadcast,
+	}).Debug("Swap transaction signed")
+
+	return common.EncodePayload(&common.FinalizedDocument{
+		TxID: txID,
+		Tx:   signed.Hex,
+	}, common.PayloadJson)
+}
+
+// CheckTerms return ErrTermsMismatch if the transaction wallet inputs and outputs do not match terms
+// Wallet outputs are unblinded with the wallet blinding keys.
+func (p *Backend) CheckTerms(ctx context.Context, tx string, terms common.SwapTerms) error {
+	unblinded, err := unblindRawTransaction(ctx, p.rpc, tx)
+	if err != nil {
+		return err
+	}
+	decoded, err := decodeRawTransaction(ctx, p.rpc, unblinded)
+	if err != nil {
+		return err
+	}
+
+	reserved := make(map[common.Outpoint]bool)
+	for _, input := range terms.Inputs {
+		reserved[input.Outpoint] = true
+	}
+
+	isMine := make(map[string]bool)
+	var walletInputs []common.Outpoint
+	for _, input := range decoded.Vin {
+		outpoint := common.Outpoint{TxID: input.TxID, Vout: input.Vout}
+		if reserved[outpoint] {
+			walletInputs = append(walletInputs, outpoint)
+			continue
+		}
+		out, ok, err := getTxOut(ctx, p.rpc, input.TxID, input.Vout)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		mine, err := p.isMine(ctx, isMine, out.ScriptPubKey)
+		if err != nil {
+			return err
+		}
+		if mine {
+			walletInputs = append(walletInputs, outpoint)
+		}
+	}
+
+	received := make(map[common.AssetID]common.Amount)
+	for _, output := range decoded.Vout {
+		mine, err := p.isMine(ctx, isMine, output.ScriptPubKey)
+		if err != nil {
+			return err
+		}
+		if mine {
+			received[common.AssetID(output.Asset)] += output.Value
+		}
+	}
+
+	return terms.Check(walletInputs, received)
+}
+
+func (p *Backend) Broadcast(ctx context.Context, tx string) (string, error) {
+	return sendRawTransaction(ctx, p.rpc, tx)
+}
+
+// TxID return the transaction id of a raw transaction
+func (p *Backend) TxID(ctx context.Context, tx string) (string, error) {
+	decoded, err := decodeRawTransaction(ctx, p.rpc, tx)
+	if err != nil {
+		return "", err
+	}
+	return decoded.TxID, nil
+}
+
+// Cancel double spend the proposal inputs back to the wallet, so the proposal can not be accepted anymore
+func (p *Backend) Cancel(ctx context.Context, payload common.Payload, feeRate common.Amount) (common.Payload, error) {
+	log := logger.Logger(ctx).WithField("Method", "Liquid.native.Cancel")
+
+	proposal, err := payload.Proposal()
+	if err != nil {
+		return "", err
+	}
+	proposerAsset := string(proposal.AssetP)
+
+	// proposal inputs must still be wallet unspents
+	unspents, err := listUnspent(ctx, p.rpc, proposerAsset)
+	if err != nil {
+		return "", err
+	}
+	var selected []Unspent
+	var total common.Amount
+	for _, input := range proposal.Inputs {
+		utxo, ok := findUnspent(unspents, input.TxID, input.Vout)
+		if !ok {
+			return "", common.ErrInvalidSwapState
+		}
+		selected = append(selected, utxo)
+		total += utxo.Amount
+	}
+
+	policy, err := policyAsset(ctx, p.rpc)
+	if err != nil {
+		return "", err
+	}
+
+	address, err := getNewAddress(ctx, p.rpc)
+	if err != nil {
+		return "", err
+	}
+
+	var outputs []TxOutput
+	if proposerAsset == policy {
+		fee := estimateFee(len(selected), 1, feeRate)
+		if total <= fee {
+			return "", ErrInsufficientFunds
+		}
+		outputs = append(outputs,
+			TxOutput{Address: address, Amount: total - fee, Asset: proposerAsset},
+			TxOutput{Address: FeeAddress, Amount: fee, Asset: policy},
+		)
+	} else {
+		fee := estimateFee(len(selected)+1, 2, feeRate)
+		fundings, totals, err := p.selectFundings(ctx, map[string]common.Amount{policy: fee})
+		if err != nil {
+			return "", err
+		}
+		selected = append(selected, fundings...)
+
+		outputs = append(outputs, TxOutput{Address: address, Amount: total, Asset: proposerAsset})
+		if change := totals[policy] - fee; change > 0 {
+			changeAddress, err := getRawChangeAddress(ctx, p.rpc)
+			if err != nil {
+				return "", err
+			}
+			outputs = append(outputs, TxOutput{Address: changeAddress, Amount: change, Asset: policy})
+		}
+		outputs = append(outputs, TxOutput{Address: FeeAddress, Amount: fee, Asset: policy})
+	}
+
+	txHex, err := createRawTransaction(ctx, p.rpc, txInputs(selected), outputs)
+	if err != nil {
+		return "", err
+	}
+	blinded, err := rawBlindRawTransaction(ctx, p.rpc, txHex, selected)
+	if err != nil {
+		return "", err
+	}
+	signed, err := signRawTransactionWithWallet(ctx, p.rpc, blinded)
+	if err != nil {
+		return "", err
+	}
+	if !signed.Complete {
+		return "", ErrIncompleteTransaction
+	}
+
+	txID, err := sendRawTransaction(ctx, p.rpc, signed.Hex)
+	if err != nil {
+		return "", err
+	}
+
+	log.WithFields(logrus.Fields{
+		"Inputs": len(selected),
+		"TxID":   txID,
+	}).Debug("Proposal cancelled")
+
+	return common.EncodePayload(&common.CancelledDocument{
+		CancelTxID: txID,
+		Tx:         signed.Hex,
+	}, common.PayloadJson)
+}
+
+func (p *Backend) LockUnspents(ctx context.Context, outpoints []common.Outpoint) error {
+	if len(outpoints) == 0 {
+		return nil
+	}
+	return lockUnspent(ctx, p.rpc, false, outpoints)
+}
+
+func (p *Backend) UnlockUnspents(ctx context.Context, outpoints []common.Outpoint) error {
+	if len(outpoints) == 0 {
+		return nil
+	}
+	return lockUnspent(ctx, p.rpc, true, outpoints)
+}
+
+func (p *Backend) Balances(ctx context.Context) (map[common.AssetID]common.Amount, error) {
+	unspents, err := listUnspent(ctx, p.rpc, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[common.AssetID]common.Amount)
+	for _, utxo := range unspents {
+		if !utxo.Spendable {
+			continue
+		}
+		result[common.AssetID(utxo.Asset)] += utxo.Amount
+	}
+	return result, nil
+}
+
+func (p *Backend) Confirmations(ctx context.Context, txID string) (int, error) {
+	tx, err := getTransaction(ctx, p.rpc, txID)
+	if err != nil {
+		return 0, err
+	}
+	if tx.Confirmations < 0 {
+		return 0, common.ErrTxConflicted
+	}
+	return tx.Confirmations, nil
+}
+
+// selectFundings select unspents for each asset amount
+func (p *Backend) selectFundings(ctx context.Context, fundings map[string]common.Amount) ([]Unspent, map[string]common.Amount, error) {
+	var result []Unspent
+	totals := make(map[string]common.Amount)
+	for _, asset := range fundingAssets(fundings) {
+		amount := fundings[asset]
+		unspents, err := listUnspent(ctx, p.rpc, asset)
+		if err != nil {
+			return nil, nil, err
+		}
+		selected, total, err := selectCoins(unspents, amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, selected...)
+		totals[asset] = total
+	}
+	return result, totals, nil
+}
+
+// fundingAssets return sorted assets, so inputs and outputs order is deterministic
+func fundingAssets(fundings map[string]common.Amount) []string {
+	var result []string
+	for asset := range fundings {
+		result = append(result, asset)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// checkProposal verify the proposer transaction match the proposal amounts
+func checkProposal(proposal common.ProposalDocument, tx transaction) error {
+	if len(tx.Inputs) != len(proposal.Inputs) {
+		return ErrProposalMismatch
+	}
+	proposerAsset := string(proposal.AssetP)
+	receiverAsset := string(proposal.AssetR)
+
+	var inputTotal common.Amount
+	for i, input := range proposal.Inputs {
+		if tx.Inputs[i].TxID() != input.TxID || int(tx.Inputs[i].Index) != input.Vout {
+			return ErrProposalMismatch
+		}
+		if input.Asset != proposerAsset {
+			return ErrProposalMismatch
+		}
+		inputTotal += input.Amount
+	}
+
+	var requested, change common.Amount
+	for _, output := range tx.Outputs {
+		asset, okAsset := output.ExplicitAsset()
+		value, okValue := output.ExplicitValue()
+		if !okAsset || !okValue {
+			return ErrProposalMismatch
+		}
+		switch asset {
+		case receiverAsset:
+			requested += value
+		case proposerAsset:
+			change += value
+		default:
+			return ErrProposalMismatch
+		}
+	}
+
+	if requested != proposal.AmountR || inputTotal-change != proposal.AmountP {
+		return ErrProposalMismatch
+	}
+	return nil
+}
+
+// isMine return true if a script address belongs to the wallet, cache is updated with addresses ownership
+func (p *Backend) isMine(ctx context.Context, cache map[string]bool, script ScriptPubKey) (bool, error) {
+	for _, address := range script.Addresses {
+		mine, ok := cache[address]
+		if !ok {
+			info, err := getAddressInfo(ctx, p.rpc, address)
+			if err != nil {
+				return false, err
+			}
+			mine = info.IsMine
+			cache[address] = mine
+		}
+		if mine {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func findUnspent(unspents []Unspent, txID string, vout int) (Unspent, bool) {
+	for _, utxo := range unspents {
+		if utxo.TxID == txID && utxo.Vout == vout {
+			return utxo, true
+		}
+	}
+	return Unspent{}, false
+}
+
+func txInputs(unspents []Unspent) []TxInput {
+	var result []TxInput
+	for _, utxo := range unspents {
+		result = append(result, TxInput{TxID: utxo.TxID, Vout: utxo.Vout})
+	}
+	return result
+}
+
+func inputBlinders(unspents []Unspent) []common.InputBlinder {
+	var result []common.InputBlinder
+	for _, utxo := range unspents {
+		result = append(result, common.InputBlinder{
+			TxID:          utxo.TxID,
+			Vout:          utxo.Vout,
+			Asset:         utxo.Asset,
+			Amount:        utxo.Amount,
+			AssetBlinder:  utxo.AssetBlinder,
+			AmountBlinder: utxo.AmountBlinder,
+		})
+	}
+	return result
+}
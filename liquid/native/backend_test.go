@@ -0,0 +1,192 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func readPayload(t *testing.T, name string) common.Payload {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return common.Payload(strings.TrimSpace(string(data)))
+}
+
+// liquidswap-cli documents are python json with float amounts
+func Test_cliProposalDocument(t *testing.T) {
+	t.Parallel()
+
+	payload := readPayload(t, "proposal.txt")
+	if payload.Stage() != common.PayloadStageProposal {
+		t.Fatalf("Payload.Stage() = %v, want %v", payload.Stage(), common.PayloadStageProposal)
+	}
+
+	proposal, err := payload.Proposal()
+	if err != nil {
+		t.Fatalf("Payload.Proposal() error = %v", err)
+	}
+	if proposal.AmountP != 200000 || proposal.AmountR != 140000 || proposal.Inputs[0].Amount != 300000 {
+		t.Errorf("Payload.Proposal() wrong amounts %+v", proposal)
+	}
+
+	tx, err := decodeTransaction(proposal.Tx)
+	if err != nil {
+		t.Fatalf("decodeTransaction() error = %v", err)
+	}
+	if err := checkProposal(proposal, tx); err != nil {
+		t.Errorf("checkProposal() error = %v", err)
+	}
+	if tx.Encode() != proposal.Tx {
+		t.Errorf("transaction.Encode() round trip = %v, want %v", tx.Encode(), proposal.Tx)
+	}
+
+	// encoded document must be read back with the same terms
+	encoded, err := common.EncodePayload(&proposal, common.PayloadBase64)
+	if err != nil {
+		t.Fatalf("EncodePayload() error = %v", err)
+	}
+	again, err := encoded.Proposal()
+	if err != nil {
+		t.Fatalf("Payload.Proposal() error = %v", err)
+	}
+	if !reflect.DeepEqual(again, proposal) {
+		t.Errorf("Payload.Proposal() round trip = %+v, want %+v", again, proposal)
+	}
+
+	tampered := proposal
+	tampered.AmountR++
+	if err := checkProposal(tampered, tx); err != ErrProposalMismatch {
+		t.Errorf("checkProposal() error = %v, want %v", err, ErrProposalMismatch)
+	}
+}
+
+func Test_cliAcceptedDocument(t *testing.T) {
+	t.Parallel()
+
+	payload := readPayload(t, "accepted.txt")
+	if payload.Stage() != common.PayloadStageAccepted {
+		t.Fatalf("Payload.Stage() = %v, want %v", payload.Stage(), common.PayloadStageAccepted)
+	}
+
+	accepted, err := payload.Accepted()
+	if err != nil {
+		t.Fatalf("Payload.Accepted() error = %v", err)
+	}
+	if _, err := decodeTransaction(accepted.Tx); err != nil {
+		t.Errorf("decodeTransaction() error = %v", err)
+	}
+}
+
+func Test_fundingAssets(t *testing.T) {
+	t.Parallel()
+
+	fundings := map[string]common.Amount{
+		"cc": 1,
+		"aa": 2,
+		"bb": 3,
+	}
+	want := []string{"aa", "bb", "cc"}
+	for i := 0; i < 10; i++ {
+		if got := fundingAssets(fundings); !reflect.DeepEqual(got, want) {
+			t.Fatalf("fundingAssets() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBackend_FinalizeTerms(t *testing.T) {
+	t.Parallel()
+
+	const (
+		assetP   = "ce091c998b83c78bb71a632313ba3760f1763d9cfcffae02258ffa9865a37bd2"
+		assetR   = "0e99c1a6da379d1f4151fb9df90449d40d0608f6cb33a5bcbfc8c265f42bab0a"
+		policy   = "6f0279e9ed041c3d710a9f57d0c02928416460c4b722ae3457a11eec381c526d"
+		proposer = "ex1qproposer"
+		acceptor = "ex1qacceptor"
+	)
+	reserved := common.Outpoint{TxID: strings.Repeat("a", 64), Vout: 0}
+	acceptorInput := common.Outpoint{TxID: strings.Repeat("b", 64), Vout: 1}
+
+	terms := common.SwapTerms{
+		Proposal: common.ProposalInfo{
+			ProposerAsset:  assetP,
+			ProposerAmount: 1000,
+			ReceiverAsset:  assetR,
+			ReceiverAmount: 1400,
+		},
+		Inputs: []common.ReservedInput{
+			{Outpoint: reserved, Asset: assetP, Amount: 1500},
+		},
+	}
+	accepted, err := common.EncodePayload(&common.AcceptedDocument{
+		ProtocolVersion: common.ProtocolVersion,
+		Tx:              "00",
+		Fee:             300,
+	}, common.PayloadBase64)
+	if err != nil {
+		t.Fatalf("EncodePayload() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		received      string // proposer requested output
+		address       string // proposer requested output address
+		acceptorOwner string // acceptor input address
+		wantErr       error
+	}{
+		{"valid", "0.00001400", proposer, acceptor, nil},
+		{"changedAmount", "0.00001000", proposer, acceptor, common.ErrTermsMismatch},
+		{"changedAddress", "0.00001400", acceptor, acceptor, common.ErrTermsMismatch},
+		{"walletInput", "0.00001400", proposer, proposer, common.ErrTermsMismatch},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var signed int32
+			rpc := rpcResultServer(t, func(method string, params []interface{}) string {
+				switch method {
+				case CmdUnblindRawTransaction:
+					return `{"hex": "00"}`
+				case CmdDecodeRawTransaction:
+					return fmt.Sprintf(`{"txid": "%s", "vin": [{"txid": "%s", "vout": %d}, {"txid": "%s", "vout": %d}], "vout": [
+						{"n": 0, "value": %s, "asset": "%s", "scriptPubKey": {"addresses": ["%s"]}},
+						{"n": 1, "value": 0.00000500, "asset": "%s", "scriptPubKey": {"addresses": ["%s"]}},
+						{"n": 2, "scriptPubKey": {"addresses": ["%s"]}},
+						{"n": 3, "value": 0.00000300, "asset": "%s", "scriptPubKey": {"type": "fee"}}
+					]}`, strings.Repeat("c", 64), reserved.TxID, reserved.Vout, acceptorInput.TxID, acceptorInput.Vout,
+						tt.received, assetR, tt.address, assetP, proposer, acceptor, policy)
+				case CmdGetTxOut:
+					return fmt.Sprintf(`{"scriptPubKey": {"addresses": ["%s"]}}`, tt.acceptorOwner)
+				case CmdGetAddressInfo:
+					return fmt.Sprintf(`{"ismine": %t}`, params[0] == proposer)
+				case CmdSignRawTransactionWithWallet:
+					atomic.AddInt32(&signed, 1)
+					return `{"hex": "00", "complete": true}`
+				}
+				return "null"
+			})
+
+			_, err := NewBackendWithRpc(rpc).Finalize(context.Background(), accepted, terms, false)
+			if err != tt.wantErr {
+				t.Fatalf("Backend.Finalize() error = %v, want %v", err, tt.wantErr)
+			}
+			if wantSigned := tt.wantErr == nil; (atomic.LoadInt32(&signed) > 0) != wantSigned {
+				t.Errorf("Backend.Finalize() signed = %v, want %v", !wantSigned, wantSigned)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"sort"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+const (
+	// virtual size estimation for blinded transactions
+	txBaseVSize           = 11
+	txInputVSize          = 68
+	txBlindedOutputVSize  = 900
+	txExplicitOutputVSize = 45
+)
+
+var (
+	ErrInsufficientFunds = common.ErrInsufficientFunds
+)
+
+// selectCoins select spendable unspents, largest first, until amount is reached
+// returns selected unspents and total amount
+func selectCoins(unspents []Unspent, amount common.Amount) ([]Unspent, common.Amount, error) {
+	var candidates []Unspent
+	for _, utxo := range unspents {
+		if !utxo.Spendable {
+			continue
+		}
+		candidates = append(candidates, utxo)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Amount > candidates[j].Amount
+	})
+
+	var result []Unspent
+	var total common.Amount
+	for _, utxo := range candidates {
+		if total >= amount {
+			break
+		}
+		result = append(result, utxo)
+		total += utxo.Amount
+	}
+	if total < amount || len(result) == 0 {
+		return nil, 0, ErrInsufficientFunds
+	}
+
+	return result, total, nil
+}
+
+// estimateFee return fee for the transaction size and feeRate in satoshi/Kb
+func estimateFee(inputs, blindedOutputs int, feeRate common.Amount) common.Amount {
+	vsize := txBaseVSize +
+		inputs*txInputVSize +
+		blindedOutputs*txBlindedOutputVSize +
+		txExplicitOutputVSize
+
+	return (feeRate*common.Amount(vsize) + 999) / 1000
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"testing"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func Test_selectCoins(t *testing.T) {
+	t.Parallel()
+
+	unspents := []Unspent{
+		{TxID: "a", Amount: 50000000, Spendable: true},
+		{TxID: "b", Amount: 200000000, Spendable: true},
+		{TxID: "c", Amount: 100000000, Spendable: true},
+		{TxID: "d", Amount: 1000000000, Spendable: false},
+	}
+
+	tests := []struct {
+		name      string
+		amount    common.Amount
+		wantCount int
+		wantTotal common.Amount
+		wantErr   bool
+	}{
+		{"largest", 150000000, 1, 200000000, false},
+		{"two", 250000000, 2, 300000000, false},
+		{"all", 350000000, 3, 350000000, false},
+
+		{"insufficient", 350000001, 0, 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := selectCoins(unspents, tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("selectCoins() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("selectCoins() count = %v, want %v", len(got), tt.wantCount)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("selectCoins() total = %v, want %v", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func Test_estimateFee(t *testing.T) {
+	t.Parallel()
+
+	// 11 + 2*68 + 3*900 + 45 = 2892 vbytes at 1000 sat/Kb
+	if got := estimateFee(2, 3, 1000); got != 2892 {
+		t.Errorf("estimateFee() = %v, want %v", got, 2892)
+	}
+}
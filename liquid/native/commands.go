@@ -0,0 +1,226 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"context"
+	"errors"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+const (
+	CmdListUnspent                  = "listunspent"
+	CmdGetNewAddress                = "getnewaddress"
+	CmdGetRawChangeAddress          = "getrawchangeaddress"
+	CmdCreateRawTransaction         = "createrawtransaction"
+	CmdRawBlindRawTransaction       = "rawblindrawtransaction"
+	CmdSignRawTransactionWithWallet = "signrawtransactionwithwallet"
+	CmdSendRawTransaction           = "sendrawtransaction"
+	CmdDecodeRawTransaction         = "decoderawtransaction"
+	CmdDumpAssetLabels              = "dumpassetlabels"
+	CmdLockUnspent                  = "lockunspent"
+	CmdGetTransaction               = "gettransaction"
+	CmdUnblindRawTransaction        = "unblindrawtransaction"
+	CmdGetTxOut                     = "gettxout"
+	CmdGetAddressInfo               = "getaddressinfo"
+
+	PolicyAssetLabel = "bitcoin"
+	FeeAddress       = "fee"
+)
+
+type Unspent struct {
+	TxID          string        `json:"txid"`
+	Vout          int           `json:"vout"`
+	Amount        common.Amount `json:"amount"`
+	Asset         string        `json:"asset"`
+	AmountBlinder string        `json:"amountblinder"`
+	AssetBlinder  string        `json:"assetblinder"`
+	Spendable     bool          `json:"spendable"`
+}
+
+type TxInput struct {
+	TxID     string `json:"txid"`
+	Vout     int    `json:"vout"`
+	Sequence uint32 `json:"sequence,omitempty"`
+}
+
+type TxOutput struct {
+	Address string
+	Amount  common.Amount
+	Asset   string
+}
+
+// WalletTransaction is a wallet transaction, negative confirmations for conflicted transactions
+type WalletTransaction struct {
+	TxID          string `json:"txid"`
+	Confirmations int    `json:"confirmations"`
+}
+
+type SignedTransaction struct {
+	Hex      string `json:"hex"`
+	Complete bool   `json:"complete"`
+}
+
+type DecodedTransaction struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		TxID string `json:"txid"`
+		Vout int    `json:"vout"`
+	} `json:"vin"`
+	Vout []struct {
+		N            int           `json:"n"`
+		Value        common.Amount `json:"value"`
+		Asset        string        `json:"asset"`
+		ScriptPubKey ScriptPubKey  `json:"scriptPubKey"`
+	} `json:"vout"`
+}
+
+// ScriptPubKey list output addresses, none for the fee output
+type ScriptPubKey struct {
+	Type      string   `json:"type"`
+	Addresses []string `json:"addresses"`
+}
+
+// TxOut is an unspent transaction output
+type TxOut struct {
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+type AddressInfo struct {
+	IsMine bool `json:"ismine"`
+}
+
+// listUnspent return wallet unspents for asset, all assets if asset is empty
+// locked unspents are not listed
+func listUnspent(ctx context.Context, rpc *RpcClient, asset string) ([]Unspent, error) {
+	query := make(map[string]interface{})
+	if len(asset) > 0 {
+		query["asset"] = asset
+	}
+
+	var result []Unspent
+	err := rpc.Call(ctx, &result, CmdListUnspent, 1, 9999999, []string{}, false, query)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// lockUnspent lock or unlock outpoints for wallet coin selection
+func lockUnspent(ctx context.Context, rpc *RpcClient, unlock bool, outpoints []common.Outpoint) error {
+	var result bool
+	return rpc.Call(ctx, &result, CmdLockUnspent, unlock, outpoints)
+}
+
+func getNewAddress(ctx context.Context, rpc *RpcClient) (string, error) {
+	var result string
+	err := rpc.Call(ctx, &result, CmdGetNewAddress)
+	return result, err
+}
+
+func getRawChangeAddress(ctx context.Context, rpc *RpcClient) (string, error) {
+	var result string
+	err := rpc.Call(ctx, &result, CmdGetRawChangeAddress)
+	return result, err
+}
+
+// createRawTransaction create an unblinded transaction
+// the fee output use FeeAddress as Address
+func createRawTransaction(ctx context.Context, rpc *RpcClient, inputs []TxInput, outputs []TxOutput) (string, error) {
+	var outs []map[string]common.Amount
+	assets := make(map[string]string)
+	for _, output := range outputs {
+		outs = append(outs, map[string]common.Amount{output.Address: output.Amount})
+		assets[output.Address] = output.Asset
+	}
+
+	var result string
+	err := rpc.Call(ctx, &result, CmdCreateRawTransaction, inputs, outs, 0, false, assets)
+	return result, err
+}
+
+func rawBlindRawTransaction(ctx context.Context, rpc *RpcClient, txHex string, inputs []Unspent) (string, error) {
+	var amountBlinders, assets, assetBlinders []string
+	var amounts []common.Amount
+	for _, input := range inputs {
+		amountBlinders = append(amountBlinders, input.AmountBlinder)
+		amounts = append(amounts, input.Amount)
+		assets = append(assets, input.Asset)
+		assetBlinders = append(assetBlinders, input.AssetBlinder)
+	}
+
+	var result string
+	err := rpc.Call(ctx, &result, CmdRawBlindRawTransaction, txHex, amountBlinders, amounts, assets, assetBlinders, "", false)
+	return result, err
+}
+
+func signRawTransactionWithWallet(ctx context.Context, rpc *RpcClient, txHex string) (SignedTransaction, error) {
+	var result SignedTransaction
+	err := rpc.Call(ctx, &result, CmdSignRawTransactionWithWallet, txHex)
+	return result, err
+}
+
+func sendRawTransaction(ctx context.Context, rpc *RpcClient, txHex string) (string, error) {
+	var result string
+	err := rpc.Call(ctx, &result, CmdSendRawTransaction, txHex)
+	return result, err
+}
+
+// getTransaction return ErrTxDropped if the transaction is unknown to the wallet
+func getTransaction(ctx context.Context, rpc *RpcClient, txID string) (WalletTransaction, error) {
+	var result WalletTransaction
+	err := rpc.Call(ctx, &result, CmdGetTransaction, txID)
+
+	var rpcError *RpcError
+	if errors.As(err, &rpcError) && rpcError.Code == rpcInvalidAddressOrKey {
+		return WalletTransaction{}, common.ErrTxDropped
+	}
+	return result, err
+}
+
+func decodeRawTransaction(ctx context.Context, rpc *RpcClient, txHex string) (DecodedTransaction, error) {
+	var result DecodedTransaction
+	err := rpc.Call(ctx, &result, CmdDecodeRawTransaction, txHex)
+	return result, err
+}
+
+// unblindRawTransaction unblind outputs with the wallet blinding keys, other outputs stay blinded
+func unblindRawTransaction(ctx context.Context, rpc *RpcClient, txHex string) (string, error) {
+	var result struct {
+		Hex string `json:"hex"`
+	}
+	err := rpc.Call(ctx, &result, CmdUnblindRawTransaction, txHex)
+	return result.Hex, err
+}
+
+// getTxOut return false if the output is spent or unknown
+func getTxOut(ctx context.Context, rpc *RpcClient, txID string, vout int) (TxOut, bool, error) {
+	var result *TxOut
+	err := rpc.Call(ctx, &result, CmdGetTxOut, txID, vout, true)
+	if err != nil || result == nil {
+		return TxOut{}, false, err
+	}
+	return *result, true, nil
+}
+
+func getAddressInfo(ctx context.Context, rpc *RpcClient, address string) (AddressInfo, error) {
+	var result AddressInfo
+	err := rpc.Call(ctx, &result, CmdGetAddressInfo, address)
+	return result, err
+}
+
+func policyAsset(ctx context.Context, rpc *RpcClient) (string, error) {
+	var labels map[string]string
+	err := rpc.Call(ctx, &labels, CmdDumpAssetLabels)
+	if err != nil {
+		return "", err
+	}
+	asset, ok := labels[PolicyAssetLabel]
+	if !ok {
+		return "", ErrRpcError
+	}
+	return asset, nil
+}
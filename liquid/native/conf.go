@@ -0,0 +1,161 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultChain   = "liquidv1"
+	DefaultRpcHost = "127.0.0.1"
+	DefaultRpcPort = 7041
+
+	cookieUser = "__cookie__"
+)
+
+var (
+	ErrInvalidConf = errors.New("Invalid Elements Conf")
+)
+
+// RpcOptions are the elementsd JSON-RPC settings read from elements.conf
+type RpcOptions struct {
+	Chain    string
+	HostName string
+	Port     int
+	User     string
+	Password string
+	Wallet   string
+}
+
+// LoadElementsConf read RpcOptions from an elements.conf file
+func LoadElementsConf(elementsConf string) (RpcOptions, error) {
+	file, err := os.Open(elementsConf)
+	if err != nil {
+		return RpcOptions{}, err
+	}
+	defer file.Close()
+
+	options, err := parseElementsConf(file)
+	if err != nil {
+		return RpcOptions{}, err
+	}
+
+	// resolve cookie file if no credentials are set
+	if len(options.User) == 0 {
+		cookieFile := options.cookieFile
+		if len(cookieFile) == 0 {
+			cookieFile = path.Join(path.Dir(elementsConf), options.Chain, ".cookie")
+		}
+		if !path.IsAbs(cookieFile) {
+			cookieFile = path.Join(path.Dir(elementsConf), cookieFile)
+		}
+		cookie, err := ioutil.ReadFile(cookieFile)
+		if err != nil {
+			return RpcOptions{}, err
+		}
+		options.User = cookieUser
+		options.Password = strings.TrimPrefix(strings.TrimSpace(string(cookie)), cookieUser+":")
+	}
+
+	return options.RpcOptions, nil
+}
+
+type parsedConf struct {
+	RpcOptions
+	cookieFile string
+}
+
+// parseElementsConf read RpcOptions from elements.conf content
+// Values from the active chain section override global ones
+func parseElementsConf(reader io.Reader) (parsedConf, error) {
+	global := make(map[string]string)
+	sections := make(map[string]map[string]string)
+
+	current := global
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := sections[name]; !ok {
+				sections[name] = make(map[string]string)
+			}
+			current = sections[name]
+			continue
+		}
+
+		toks := strings.SplitN(line, "=", 2)
+		if len(toks) != 2 {
+			return parsedConf{}, ErrInvalidConf
+		}
+		current[strings.TrimSpace(toks[0])] = strings.TrimSpace(toks[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return parsedConf{}, err
+	}
+
+	chain := DefaultChain
+	if value, ok := global["chain"]; ok {
+		chain = value
+	}
+
+	get := func(key string) string {
+		if section, ok := sections[chain]; ok {
+			if value, ok := section[key]; ok {
+				return value
+			}
+		}
+		return global[key]
+	}
+
+	result := parsedConf{
+		RpcOptions: RpcOptions{
+			Chain:    chain,
+			HostName: DefaultRpcHost,
+			Port:     DefaultRpcPort,
+			User:     get("rpcuser"),
+			Password: get("rpcpassword"),
+			Wallet:   get("wallet"),
+		},
+		cookieFile: get("rpccookiefile"),
+	}
+
+	if host := get("rpcconnect"); len(host) > 0 {
+		result.HostName = host
+		// rpcconnect may contains the port
+		if i := strings.LastIndex(host, ":"); i > 0 {
+			port, err := strconv.Atoi(host[i+1:])
+			if err != nil {
+				return parsedConf{}, ErrInvalidConf
+			}
+			result.HostName = host[:i]
+			result.Port = port
+		}
+	}
+	if port := get("rpcport"); len(port) > 0 {
+		value, err := strconv.Atoi(port)
+		if err != nil {
+			return parsedConf{}, ErrInvalidConf
+		}
+		result.Port = value
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseElementsConf(t *testing.T) {
+	t.Parallel()
+
+	const sectionConf = `
+# liquid node
+chain=liquidv1
+rpcuser=global
+rpcpassword=secret
+
+[liquidv1]
+rpcuser=liquid # section value
+rpcport=18884
+wallet=swap
+`
+
+	tests := []struct {
+		name    string
+		conf    string
+		want    RpcOptions
+		wantErr bool
+	}{
+		{"default", "", RpcOptions{Chain: DefaultChain, HostName: DefaultRpcHost, Port: DefaultRpcPort}, false},
+		{"section", sectionConf, RpcOptions{Chain: "liquidv1", HostName: DefaultRpcHost, Port: 18884, User: "liquid", Password: "secret", Wallet: "swap"}, false},
+		{"rpcconnect", "rpcconnect=elements:7040", RpcOptions{Chain: DefaultChain, HostName: "elements", Port: 7040}, false},
+
+		{"invalidLine", "rpcuser", RpcOptions{}, true},
+		{"invalidPort", "rpcport=port", RpcOptions{}, true},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseElementsConf(strings.NewReader(tt.conf))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseElementsConf() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got.RpcOptions, tt.want) {
+				t.Errorf("parseElementsConf() = %+v, want %+v", got.RpcOptions, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/condensat/bank-core/logger"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+var (
+	ErrRpcError        = errors.New("Rpc Error")
+	ErrRpcUnauthorized = errors.New("Rpc Unauthorized")
+)
+
+// RpcError is the error object returned by elementsd
+type RpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	// elementsd rpc error codes
+	rpcInvalidAddressOrKey     = -5
+	rpcWalletInsufficientFunds = -6
+	rpcInWarmup                = -28
+)
+
+func (p *RpcError) Error() string {
+	return fmt.Sprintf("%s (%d)", p.Message, p.Code)
+}
+
+// Is match elementsd error codes with swap errors
+func (p *RpcError) Is(target error) bool {
+	switch p.Code {
+	case rpcWalletInsufficientFunds:
+		return target == common.ErrInsufficientFunds
+	case rpcInWarmup:
+		return target == common.ErrBackendUnavailable
+
+	default:
+		return false
+	}
+}
+
+type rpcRequest struct {
+	JsonRpc string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RpcError       `json:"error"`
+}
+
+// RpcClient is a minimal elementsd JSON-RPC client
+type RpcClient struct {
+	endpoint string
+	user     string
+	password string
+	client   *http.Client
+
+	nextID uint64
+}
+
+func NewRpcClient(options RpcOptions) *RpcClient {
+	endpoint := fmt.Sprintf("http://%s:%d/", options.HostName, options.Port)
+	if len(options.Wallet) > 0 {
+		endpoint += "wallet/" + options.Wallet
+	}
+
+	return &RpcClient{
+		endpoint: endpoint,
+		user:     options.User,
+		password: options.Password,
+		client:   &http.Client{},
+	}
+}
+
+// Call perform the rpc method and decode result into out
+func (p *RpcClient) Call(ctx context.Context, out interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(&rpcRequest{
+		JsonRpc: "1.0",
+		ID:      atomic.AddUint64(&p.nextID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if len(p.user) > 0 {
+		req.SetBasicAuth(p.user, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logger.Logger(ctx).WithError(err).
+			WithField("Method", "Liquid.native.RpcClient").
+			Error("Rpc request failed")
+		return common.ErrBackendUnavailable
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrRpcUnauthorized
+	}
+
+	// elementsd reply with error status and a json body on rpc error
+	var result rpcResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return ErrRpcError
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(result.Result, out)
+}
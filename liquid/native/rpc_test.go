@@ -0,0 +1,94 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+// rpcErrorServer reply with the rpc error code to all requests
+func rpcErrorServer(t *testing.T, code int) *RpcClient {
+	return rpcTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `{"result": null, "error": {"code": %d, "message": "rpc error"}, "id": 1}`, code)
+	}))
+}
+
+// rpcResultServer reply with the json result returned by results for the request method and params
+func rpcResultServer(t *testing.T, results func(method string, params []interface{}) string) *RpcClient {
+	return rpcTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"result": %s, "error": null, "id": %d}`, results(request.Method, request.Params), request.ID)
+	}))
+}
+
+func rpcTestClient(t *testing.T, handler http.Handler) *RpcClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return NewRpcClient(RpcOptions{HostName: host, Port: portNumber})
+}
+
+func TestRpcError_Is(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		code    int
+		target  error
+		wantErr bool
+	}{
+		{"insufficientFunds", rpcWalletInsufficientFunds, common.ErrInsufficientFunds, true},
+		{"warmup", rpcInWarmup, common.ErrBackendUnavailable, true},
+		{"invalidAddressOrKey", rpcInvalidAddressOrKey, common.ErrTxDropped, false},
+		{"unknown", -1, common.ErrInsufficientFunds, false},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := &RpcError{Code: tt.code}
+			if got := errors.Is(err, tt.target); got != tt.wantErr {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackend_InvalidAddressOrKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	backend := NewBackendWithRpc(rpcErrorServer(t, rpcInvalidAddressOrKey))
+
+	// unknown transaction is dropped
+	if _, err := backend.Confirmations(ctx, "txid"); err != common.ErrTxDropped {
+		t.Errorf("Backend.Confirmations() error = %v, want %v", err, common.ErrTxDropped)
+	}
+
+	// invalid address is a backend error
+	_, err := createRawTransaction(ctx, backend.rpc, nil, nil)
+	var rpcError *RpcError
+	if !errors.As(err, &rpcError) || errors.Is(err, common.ErrTxDropped) {
+		t.Errorf("createRawTransaction() error = %v, want RpcError", err)
+	}
+}
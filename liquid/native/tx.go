@@ -0,0 +1,293 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+const (
+	outpointIssuanceFlag = uint32(1 << 31)
+	outpointPeginFlag    = uint32(1 << 30)
+	outpointIndexMask    = uint32(0x3fffffff)
+	outpointNullIndex    = uint32(0xffffffff)
+
+	maxTxElements = 1 << 16
+)
+
+var (
+	ErrInvalidTransaction     = errors.New("Invalid Transaction")
+	ErrUnsupportedTransaction = errors.New("Unsupported Transaction")
+)
+
+// transaction is an unsigned elements transaction
+// Only transactions without witness and issuance are supported,
+// which is the case for the unblinded liquidswap transactions
+type transaction struct {
+	Version  uint32
+	Inputs   []txIn
+	Outputs  []txOut
+	LockTime uint32
+}
+
+type txIn struct {
+	Hash      [32]byte
+	Index     uint32
+	ScriptSig []byte
+	Sequence  uint32
+}
+
+type txOut struct {
+	Asset  []byte
+	Value  []byte
+	Nonce  []byte
+	Script []byte
+}
+
+// TxID return the reversed hex prevout hash as displayed by elementsd
+func (p *txIn) TxID() string {
+	var reversed [32]byte
+	for i := range p.Hash {
+		reversed[i] = p.Hash[len(p.Hash)-1-i]
+	}
+	return hex.EncodeToString(reversed[:])
+}
+
+// ExplicitValue return the unblinded output value
+func (p *txOut) ExplicitValue() (common.Amount, bool) {
+	if len(p.Value) != 9 || p.Value[0] != 0x01 {
+		return 0, false
+	}
+	return common.Amount(binary.BigEndian.Uint64(p.Value[1:])), true
+}
+
+// ExplicitAsset return the unblinded output asset id
+func (p *txOut) ExplicitAsset() (string, bool) {
+	if len(p.Asset) != 33 || p.Asset[0] != 0x01 {
+		return "", false
+	}
+	var reversed [32]byte
+	for i := 0; i < 32; i++ {
+		reversed[i] = p.Asset[32-i]
+	}
+	return hex.EncodeToString(reversed[:]), true
+}
+
+func decodeTransaction(txHex string) (transaction, error) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return transaction{}, ErrInvalidTransaction
+	}
+	reader := bytes.NewReader(data)
+
+	var tx transaction
+	if err := binary.Read(reader, binary.LittleEndian, &tx.Version); err != nil {
+		return transaction{}, ErrInvalidTransaction
+	}
+	flags, err := reader.ReadByte()
+	if err != nil {
+		return transaction{}, ErrInvalidTransaction
+	}
+	if flags != 0 {
+		return transaction{}, ErrUnsupportedTransaction
+	}
+
+	count, err := readVarInt(reader)
+	if err != nil || count > maxTxElements {
+		return transaction{}, ErrInvalidTransaction
+	}
+	for i := uint64(0); i < count; i++ {
+		var in txIn
+		if _, err := io.ReadFull(reader, in.Hash[:]); err != nil {
+			return transaction{}, ErrInvalidTransaction
+		}
+		if err := binary.Read(reader, binary.LittleEndian, &in.Index); err != nil {
+			return transaction{}, ErrInvalidTransaction
+		}
+		if in.Index != outpointNullIndex && in.Index&(outpointIssuanceFlag|outpointPeginFlag) != 0 {
+			return transaction{}, ErrUnsupportedTransaction
+		}
+		if in.ScriptSig, err = readVarBytes(reader); err != nil {
+			return transaction{}, ErrInvalidTransaction
+		}
+		if err := binary.Read(reader, binary.LittleEndian, &in.Sequence); err != nil {
+			return transaction{}, ErrInvalidTransaction
+		}
+		tx.Inputs = append(tx.Inputs, in)
+	}
+
+	count, err = readVarInt(reader)
+	if err != nil || count > maxTxElements {
+		return transaction{}, ErrInvalidTransaction
+	}
+	for i := uint64(0); i < count; i++ {
+		var out txOut
+		if out.Asset, err = readConfidential(reader, 33, 0x01, 0x0a, 0x0b); err != nil {
+			return transaction{}, err
+		}
+		if out.Value, err = readConfidential(reader, 9, 0x01, 0x08, 0x09); err != nil {
+			return transaction{}, err
+		}
+		if out.Nonce, err = readConfidential(reader, 33, 0x01, 0x02, 0x03); err != nil {
+			return transaction{}, err
+		}
+		if out.Script, err = readVarBytes(reader); err != nil {
+			return transaction{}, ErrInvalidTransaction
+		}
+		tx.Outputs = append(tx.Outputs, out)
+	}
+
+	if err := binary.Read(reader, binary.LittleEndian, &tx.LockTime); err != nil {
+		return transaction{}, ErrInvalidTransaction
+	}
+	if reader.Len() != 0 {
+		return transaction{}, ErrInvalidTransaction
+	}
+
+	return tx, nil
+}
+
+func (p *transaction) Encode() string {
+	var buffer bytes.Buffer
+	_ = binary.Write(&buffer, binary.LittleEndian, p.Version)
+	buffer.WriteByte(0) // no witness
+
+	writeVarInt(&buffer, uint64(len(p.Inputs)))
+	for _, in := range p.Inputs {
+		buffer.Write(in.Hash[:])
+		_ = binary.Write(&buffer, binary.LittleEndian, in.Index)
+		writeVarBytes(&buffer, in.ScriptSig)
+		_ = binary.Write(&buffer, binary.LittleEndian, in.Sequence)
+	}
+
+	writeVarInt(&buffer, uint64(len(p.Outputs)))
+	for _, out := range p.Outputs {
+		buffer.Write(confidentialOrNull(out.Asset))
+		buffer.Write(confidentialOrNull(out.Value))
+		buffer.Write(confidentialOrNull(out.Nonce))
+		writeVarBytes(&buffer, out.Script)
+	}
+
+	_ = binary.Write(&buffer, binary.LittleEndian, p.LockTime)
+
+	return hex.EncodeToString(buffer.Bytes())
+}
+
+// mergeTransactions append inputs and outputs of other to tx
+func mergeTransactions(tx, other transaction) transaction {
+	result := transaction{
+		Version:  tx.Version,
+		LockTime: tx.LockTime,
+	}
+	result.Inputs = append(result.Inputs, tx.Inputs...)
+	result.Inputs = append(result.Inputs, other.Inputs...)
+	result.Outputs = append(result.Outputs, tx.Outputs...)
+	result.Outputs = append(result.Outputs, other.Outputs...)
+	return result
+}
+
+func confidentialOrNull(data []byte) []byte {
+	if len(data) == 0 {
+		return []byte{0x00}
+	}
+	return data
+}
+
+// readConfidential read a confidential field, prefixed by its kind
+func readConfidential(reader *bytes.Reader, explicitSize int, prefixes ...byte) ([]byte, error) {
+	prefix, err := reader.ReadByte()
+	if err != nil {
+		return nil, ErrInvalidTransaction
+	}
+	if prefix == 0x00 {
+		return nil, nil
+	}
+
+	size := 33
+	if prefix == 0x01 {
+		size = explicitSize
+	}
+
+	valid := false
+	for _, p := range prefixes {
+		if prefix == p {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidTransaction
+	}
+
+	result := make([]byte, size)
+	result[0] = prefix
+	if _, err := io.ReadFull(reader, result[1:]); err != nil {
+		return nil, ErrInvalidTransaction
+	}
+	return result, nil
+}
+
+func readVarInt(reader *bytes.Reader) (uint64, error) {
+	prefix, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch prefix {
+	case 0xfd:
+		var value uint16
+		err = binary.Read(reader, binary.LittleEndian, &value)
+		return uint64(value), err
+	case 0xfe:
+		var value uint32
+		err = binary.Read(reader, binary.LittleEndian, &value)
+		return uint64(value), err
+	case 0xff:
+		var value uint64
+		err = binary.Read(reader, binary.LittleEndian, &value)
+		return value, err
+	default:
+		return uint64(prefix), nil
+	}
+}
+
+func readVarBytes(reader *bytes.Reader) ([]byte, error) {
+	size, err := readVarInt(reader)
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(reader.Len()) {
+		return nil, ErrInvalidTransaction
+	}
+	result := make([]byte, size)
+	_, err = io.ReadFull(reader, result)
+	return result, err
+}
+
+func writeVarInt(buffer *bytes.Buffer, value uint64) {
+	switch {
+	case value < 0xfd:
+		buffer.WriteByte(byte(value))
+	case value <= 0xffff:
+		buffer.WriteByte(0xfd)
+		_ = binary.Write(buffer, binary.LittleEndian, uint16(value))
+	case value <= 0xffffffff:
+		buffer.WriteByte(0xfe)
+		_ = binary.Write(buffer, binary.LittleEndian, uint32(value))
+	default:
+		buffer.WriteByte(0xff)
+		_ = binary.Write(buffer, binary.LittleEndian, value)
+	}
+}
+
+func writeVarBytes(buffer *bytes.Buffer, data []byte) {
+	writeVarInt(buffer, uint64(len(data)))
+	buffer.Write(data)
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package native
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func explicitOutput(assetByte byte, value uint64, script []byte) txOut {
+	asset := make([]byte, 33)
+	asset[0] = 0x01
+	for i := 1; i < len(asset); i++ {
+		asset[i] = assetByte
+	}
+	amount := make([]byte, 9)
+	amount[0] = 0x01
+	binary.BigEndian.PutUint64(amount[1:], value)
+
+	return txOut{Asset: asset, Value: amount, Script: script}
+}
+
+func Test_transactionRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	proposer := transaction{
+		Version: 2,
+		Inputs: []txIn{
+			{Hash: [32]byte{1}, Index: 0, ScriptSig: []byte{}, Sequence: 0xffffffff},
+		},
+		Outputs: []txOut{
+			explicitOutput(0xaa, 1000, []byte{0x00, 0x14}),
+		},
+	}
+	acceptor := transaction{
+		Version: 2,
+		Inputs: []txIn{
+			{Hash: [32]byte{2}, Index: 3, ScriptSig: []byte{}, Sequence: 0xfffffffe},
+		},
+		Outputs: []txOut{
+			explicitOutput(0xbb, 1400, []byte{0x00, 0x14}),
+			explicitOutput(0xcc, 250, []byte{}),
+		},
+	}
+
+	merged := mergeTransactions(proposer, acceptor)
+	got, err := decodeTransaction(merged.Encode())
+	if err != nil {
+		t.Fatalf("decodeTransaction() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, merged) {
+		t.Errorf("decodeTransaction() = %+v, want %+v", got, merged)
+	}
+
+	if len(got.Inputs) != 2 || len(got.Outputs) != 3 {
+		t.Errorf("mergeTransactions() wrong size %d/%d", len(got.Inputs), len(got.Outputs))
+	}
+	if value, ok := got.Outputs[1].ExplicitValue(); !ok || value != 1400 {
+		t.Errorf("ExplicitValue() = %v, want %v", value, 1400)
+	}
+	if asset, ok := got.Outputs[0].ExplicitAsset(); !ok || asset[:2] != "aa" {
+		t.Errorf("ExplicitAsset() = %v", asset)
+	}
+	if txID := got.Inputs[0].TxID(); txID[len(txID)-2:] != "01" {
+		t.Errorf("TxID() = %v", txID)
+	}
+}
+
+func Test_decodeTransaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		txHex   string
+		wantErr error
+	}{
+		{"invalidHex", "zz", ErrInvalidTransaction},
+		{"truncated", "02000000", ErrInvalidTransaction},
+		{"witness", "0200000001", ErrUnsupportedTransaction},
+		{"empty", "02000000000000" + "00000000", nil},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeTransaction(tt.txHex)
+			if err != tt.wantErr {
+				t.Errorf("decodeTransaction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"context"
+)
+
+const (
+	SwapStoreKey = "Key.SwapStoreKey"
+)
+
+func SwapStoreContext(ctx context.Context, store Store) context.Context {
+	return context.WithValue(ctx, SwapStoreKey, store)
+}
+
+func SwapStoreFromContext(ctx context.Context) Store {
+	switch store := ctx.Value(SwapStoreKey).(type) {
+	case Store:
+		return store
+
+	default:
+		return nil
+	}
+}
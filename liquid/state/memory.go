@@ -0,0 +1,145 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a process local Store
+type MemoryStore struct {
+	sync.Mutex
+	records map[uint64]SwapRecord
+	results map[resultKey]memoryResult
+
+	reservations map[uint64]Reservation
+}
+
+type resultKey struct {
+	swapID    uint64
+	operation Operation
+}
+
+type memoryResult struct {
+	result  OperationResult
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[uint64]SwapRecord),
+		results: make(map[resultKey]memoryResult),
+
+		reservations: make(map[uint64]Reservation),
+	}
+}
+
+func (p *MemoryStore) Get(ctx context.Context, swapID uint64) (SwapRecord, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	record, ok := p.records[swapID]
+	if !ok {
+		return SwapRecord{}, ErrSwapNotFound
+	}
+	return record, nil
+}
+
+func (p *MemoryStore) Transition(ctx context.Context, swapID uint64, to SwapState, update UpdateFunc) (SwapRecord, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	record, err := applyTransition(p.records[swapID], swapID, to, update)
+	if err != nil {
+		return record, err
+	}
+	p.records[swapID] = record
+
+	return record, nil
+}
+
+func (p *MemoryStore) List(ctx context.Context, state SwapState) ([]SwapRecord, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if state.IsFinal() {
+		return nil, nil
+	}
+
+	var result []SwapRecord
+	for _, record := range p.records {
+		if record.State == state {
+			result = append(result, record)
+		}
+	}
+	return sortRecords(result), nil
+}
+
+func (p *MemoryStore) Result(ctx context.Context, swapID uint64, operation Operation) (OperationResult, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	key := resultKey{swapID, operation}
+	entry, ok := p.results[key]
+	if !ok {
+		return OperationResult{}, ErrResultNotFound
+	}
+	if time.Now().After(entry.expires) {
+		delete(p.results, key)
+		return OperationResult{}, ErrResultNotFound
+	}
+	return entry.result, nil
+}
+
+func (p *MemoryStore) SaveResult(ctx context.Context, swapID uint64, operation Operation, result OperationResult) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.results[resultKey{swapID, operation}] = memoryResult{
+		result:  result,
+		expires: time.Now().Add(DefaultResultTTL),
+	}
+	return nil
+}
+
+func (p *MemoryStore) Reserve(ctx context.Context, reservation Reservation) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if err := checkReservation(reservation, p.listReservations()); err != nil {
+		return err
+	}
+	p.reservations[reservation.SwapID] = reservation
+	return nil
+}
+
+func (p *MemoryStore) Release(ctx context.Context, swapID uint64) (Reservation, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	reservation, ok := p.reservations[swapID]
+	if !ok {
+		return Reservation{}, ErrReservationNotFound
+	}
+	delete(p.reservations, swapID)
+	return reservation, nil
+}
+
+func (p *MemoryStore) Reservations(ctx context.Context, wallet string) ([]Reservation, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	return filterReservations(p.listReservations(), wallet), nil
+}
+
+func (p *MemoryStore) listReservations() []Reservation {
+	var result []Reservation
+	for _, reservation := range p.reservations {
+		result = append(result, reservation)
+	}
+	return result
+}
@@ -0,0 +1,294 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/condensat/bank-core/appcontext"
+	"github.com/condensat/bank-core/cache"
+
+	"github.com/condensat/bank-swap/liquid/common"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const (
+	redisKeyPrefix  = "liquidswap.swap"
+	transitionRetry = 8
+)
+
+var (
+	ErrRedisNotFound = errors.New("Redis Not Found")
+)
+
+// RedisStore is a Store shared by all service instances
+type RedisStore struct {
+	rdb    *redis.Client
+	prefix string
+}
+
+func NewRedisStore(ctx context.Context) (*RedisStore, error) {
+	rdb := cache.ToRedis(appcontext.Cache(ctx))
+	if rdb == nil {
+		return nil, ErrRedisNotFound
+	}
+
+	return &RedisStore{
+		rdb:    rdb,
+		prefix: common.NetworkFromContext(ctx).Namespace(redisKeyPrefix),
+	}, nil
+}
+
+func (p *RedisStore) swapKey(swapID uint64) string {
+	return fmt.Sprintf("%s.%d", p.prefix, swapID)
+}
+
+func (p *RedisStore) swapResultKey(swapID uint64, operation Operation) string {
+	return fmt.Sprintf("%s.%d.%s", p.prefix, swapID, operation)
+}
+
+// swapStateKey is the set of swaps in a non final state
+func (p *RedisStore) swapStateKey(state SwapState) string {
+	return fmt.Sprintf("%s.state.%s", p.prefix, state)
+}
+
+func (p *RedisStore) reservationsKey() string {
+	return fmt.Sprintf("%s.reservations", p.prefix)
+}
+
+func (p *RedisStore) Get(ctx context.Context, swapID uint64) (SwapRecord, error) {
+	return getRecord(p.rdb.Get(p.swapKey(swapID)))
+}
+
+func (p *RedisStore) Transition(ctx context.Context, swapID uint64, to SwapState, update UpdateFunc) (SwapRecord, error) {
+	key := p.swapKey(swapID)
+
+	var result SwapRecord
+	transition := func(tx *redis.Tx) error {
+		record, err := getRecord(tx.Get(key))
+		if err != nil && err != ErrSwapNotFound {
+			return err
+		}
+
+		result, err = applyTransition(record, swapID, to, update)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(&result)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(key, data, 0)
+
+			// update state index
+			if record.State != SwapStateNone {
+				pipe.SRem(p.swapStateKey(record.State), swapID)
+			}
+			if !to.IsFinal() {
+				pipe.SAdd(p.swapStateKey(to), swapID)
+			}
+			return nil
+		})
+		return err
+	}
+
+	err := p.watch(ctx, transition, key)
+	if err != nil {
+		return SwapRecord{}, err
+	}
+	return result, nil
+}
+
+// watch run fn in a redis transaction, retry if key was modified concurrently
+func (p *RedisStore) watch(ctx context.Context, fn func(*redis.Tx) error, key string) error {
+	for i := 0; i < transitionRetry; i++ {
+		err := p.rdb.WatchContext(ctx, fn, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		return err
+	}
+	return redis.TxFailedErr
+}
+
+func (p *RedisStore) List(ctx context.Context, state SwapState) ([]SwapRecord, error) {
+	if state.IsFinal() {
+		return nil, nil
+	}
+
+	members, err := p.rdb.SMembers(p.swapStateKey(state)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []SwapRecord
+	for _, member := range members {
+		swapID, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		record, err := p.Get(ctx, swapID)
+		if err == ErrSwapNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// index may be updated concurrently
+		if record.State != state {
+			continue
+		}
+		result = append(result, record)
+	}
+	return sortRecords(result), nil
+}
+
+func getRecord(cmd *redis.StringCmd) (SwapRecord, error) {
+	data, err := cmd.Bytes()
+	if err == redis.Nil {
+		return SwapRecord{}, ErrSwapNotFound
+	}
+	if err != nil {
+		return SwapRecord{}, err
+	}
+
+	var result SwapRecord
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return SwapRecord{}, err
+	}
+	return result, nil
+}
+
+// redisResult is the stored OperationResult, the result is encoded with its schema version
+type redisResult struct {
+	Fingerprint string
+	Result      []byte
+}
+
+func (p *RedisStore) Result(ctx context.Context, swapID uint64, operation Operation) (OperationResult, error) {
+	data, err := p.rdb.Get(p.swapResultKey(swapID, operation)).Bytes()
+	if err == redis.Nil {
+		return OperationResult{}, ErrResultNotFound
+	}
+	if err != nil {
+		return OperationResult{}, err
+	}
+
+	var stored redisResult
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		return OperationResult{}, err
+	}
+
+	result := OperationResult{
+		Fingerprint: stored.Fingerprint,
+	}
+	err = result.Result.Decode(stored.Result)
+	if err != nil {
+		return OperationResult{}, err
+	}
+	return result, nil
+}
+
+func (p *RedisStore) SaveResult(ctx context.Context, swapID uint64, operation Operation, result OperationResult) error {
+	encoded, err := result.Result.Encode()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&redisResult{
+		Fingerprint: result.Fingerprint,
+		Result:      encoded,
+	})
+	if err != nil {
+		return err
+	}
+	return p.rdb.Set(p.swapResultKey(swapID, operation), data, DefaultResultTTL).Err()
+}
+
+func (p *RedisStore) Reserve(ctx context.Context, reservation Reservation) error {
+	key := p.reservationsKey()
+	data, err := json.Marshal(&reservation)
+	if err != nil {
+		return err
+	}
+
+	return p.watch(ctx, func(tx *redis.Tx) error {
+		reservations, err := getReservations(tx.HGetAll(key))
+		if err != nil {
+			return err
+		}
+		if err := checkReservation(reservation, reservations); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+			pipe.HSet(key, fmt.Sprint(reservation.SwapID), data)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+func (p *RedisStore) Release(ctx context.Context, swapID uint64) (Reservation, error) {
+	key := p.reservationsKey()
+	field := fmt.Sprint(swapID)
+
+	var result Reservation
+	err := p.watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.HGet(key, field).Bytes()
+		if err == redis.Nil {
+			return ErrReservationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+			pipe.HDel(key, field)
+			return nil
+		})
+		return err
+	}, key)
+	if err != nil {
+		return Reservation{}, err
+	}
+	return result, nil
+}
+
+func (p *RedisStore) Reservations(ctx context.Context, wallet string) ([]Reservation, error) {
+	reservations, err := getReservations(p.rdb.HGetAll(p.reservationsKey()))
+	if err != nil {
+		return nil, err
+	}
+	return filterReservations(reservations, wallet), nil
+}
+
+func getReservations(cmd *redis.StringStringMapCmd) ([]Reservation, error) {
+	entries, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Reservation
+	for _, data := range entries {
+		var reservation Reservation
+		if err := json.Unmarshal([]byte(data), &reservation); err != nil {
+			return nil, err
+		}
+		result = append(result, reservation)
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+var (
+	ErrReservationNotFound = errors.New("Reservation Not Found")
+	ErrAlreadyReserved     = errors.New("Unspent Already Reserved")
+)
+
+// ReservedUnspent is a wallet unspent committed to a proposal
+type ReservedUnspent struct {
+	common.Outpoint
+	Asset  common.AssetID
+	Amount common.Amount
+}
+
+// Reservation list the unspents committed by an outstanding proposal
+type Reservation struct {
+	SwapID    uint64
+	Wallet    string
+	Unspents  []ReservedUnspent
+	ExpiresAt time.Time
+	// Payload is the proposal, its inputs can be spent back with SwapBackend.Cancel
+	Payload common.Payload
+}
+
+// Outpoints return reserved outpoints
+func (p *Reservation) Outpoints() []common.Outpoint {
+	var result []common.Outpoint
+	for _, utxo := range p.Unspents {
+		result = append(result, utxo.Outpoint)
+	}
+	return result
+}
+
+// Expired return true once the reservation expiry is reached
+func (p *Reservation) Expired() bool {
+	return common.Expired(p.ExpiresAt)
+}
+
+// NewReservation return reservation for the proposal payload inputs
+func NewReservation(swapID uint64, wallet string, payload common.Payload, expiresAt time.Time) (Reservation, error) {
+	proposal, err := payload.Proposal()
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	result := Reservation{
+		SwapID:    swapID,
+		Wallet:    wallet,
+		ExpiresAt: expiresAt,
+		Payload:   payload,
+	}
+	for _, input := range proposal.Inputs {
+		result.Unspents = append(result.Unspents, ReservedUnspent{
+			Outpoint: common.Outpoint{TxID: input.TxID, Vout: input.Vout},
+			Asset:    common.AssetID(input.Asset),
+			Amount:   input.Amount,
+		})
+	}
+	return result, nil
+}
+
+// ReservedAmounts return reserved amounts by asset
+func ReservedAmounts(reservations []Reservation) map[common.AssetID]common.Amount {
+	result := make(map[common.AssetID]common.Amount)
+	for _, reservation := range reservations {
+		for _, utxo := range reservation.Unspents {
+			result[utxo.Asset] += utxo.Amount
+		}
+	}
+	return result
+}
+
+// checkReservation return ErrAlreadyReserved if an outpoint is reserved by another swap
+func checkReservation(reservation Reservation, reservations []Reservation) error {
+	reserved := make(map[common.Outpoint]uint64)
+	for _, other := range reservations {
+		for _, outpoint := range other.Outpoints() {
+			reserved[outpoint] = other.SwapID
+		}
+	}
+	for _, outpoint := range reservation.Outpoints() {
+		if swapID, ok := reserved[outpoint]; ok && swapID != reservation.SwapID {
+			return ErrAlreadyReserved
+		}
+	}
+	return nil
+}
+
+// filterReservations return wallet reservations sorted by SwapID, all wallets if wallet is empty
+func filterReservations(reservations []Reservation, wallet string) []Reservation {
+	var result []Reservation
+	for _, reservation := range reservations {
+		if len(wallet) > 0 && reservation.Wallet != wallet {
+			continue
+		}
+		result = append(result, reservation)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SwapID < result[j].SwapID
+	})
+	return result
+}
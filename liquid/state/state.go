@@ -0,0 +1,145 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+type SwapState string
+
+const (
+	SwapStateNone      = SwapState("")
+	SwapStateProposed  = SwapState("Proposed")
+	SwapStateAccepted  = SwapState("Accepted")
+	SwapStateFinalized = SwapState("Finalized")
+	SwapStateBroadcast = SwapState("Broadcast")
+	SwapStateConfirmed = SwapState("Confirmed")
+	SwapStateFailed    = SwapState("Failed")
+	SwapStateExpired   = SwapState("Expired")
+	SwapStateCancelled = SwapState("Cancelled")
+)
+
+var (
+	ErrSwapNotFound      = errors.New("Swap Not Found")
+	ErrInvalidTransition = common.ErrInvalidSwapState
+	ErrStoreNotFound     = errors.New("Swap Store Not Found")
+	ErrResultNotFound    = errors.New("Swap Result Not Found")
+)
+
+// Operation identify a swap request for de-duplication
+type Operation string
+
+const (
+	OperationCreate    = Operation("create")
+	OperationAccept    = Operation("accept")
+	OperationFinalize  = Operation("finalize")
+	OperationCancel    = Operation("cancel")
+	OperationBroadcast = Operation("broadcast")
+
+	DefaultResultTTL = 24 * time.Hour
+)
+
+// OperationResult is an operation result with the fingerprint of its request
+type OperationResult struct {
+	Fingerprint string
+	Result      common.SwapProposal
+}
+
+// transitions list allowed next states
+// a swap can start as Proposed (proposer side) or Accepted (acceptor side)
+var transitions = map[SwapState][]SwapState{
+	SwapStateNone:      {SwapStateProposed, SwapStateAccepted},
+	SwapStateProposed:  {SwapStateAccepted, SwapStateFailed, SwapStateExpired, SwapStateCancelled},
+	SwapStateAccepted:  {SwapStateFinalized, SwapStateFailed, SwapStateExpired, SwapStateCancelled},
+	SwapStateFinalized: {SwapStateBroadcast, SwapStateFailed},
+	SwapStateBroadcast: {SwapStateConfirmed, SwapStateFailed},
+}
+
+// CanTransition return true if state can change from to
+func (from SwapState) CanTransition(to SwapState) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal return true if no transition is possible from state
+func (from SwapState) IsFinal() bool {
+	return from != SwapStateNone && len(transitions[from]) == 0
+}
+
+// SwapRecord is the persisted swap lifecycle
+type SwapRecord struct {
+	SwapID    uint64
+	State     SwapState
+	Wallet    string
+	Proposal  common.ProposalInfo
+	TxID      string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Confirmations int // set once confirmed
+}
+
+type UpdateFunc func(record *SwapRecord)
+
+// Store persist SwapRecord by SwapID
+type Store interface {
+	// Get return ErrSwapNotFound for unknown SwapID
+	Get(ctx context.Context, swapID uint64) (SwapRecord, error)
+	// Transition atomically change swap state, update is called before store
+	// returns ErrInvalidTransition if the current state can not change to state
+	Transition(ctx context.Context, swapID uint64, to SwapState, update UpdateFunc) (SwapRecord, error)
+	// List return swaps in state sorted by SwapID, final states are not listed
+	List(ctx context.Context, state SwapState) ([]SwapRecord, error)
+
+	// Result return ErrResultNotFound if operation was never completed for SwapID
+	Result(ctx context.Context, swapID uint64, operation Operation) (OperationResult, error)
+	// SaveResult keep operation result for DefaultResultTTL
+	SaveResult(ctx context.Context, swapID uint64, operation Operation, result OperationResult) error
+
+	// Reserve returns ErrAlreadyReserved if an unspent is reserved by another swap
+	Reserve(ctx context.Context, reservation Reservation) error
+	// Release return ErrReservationNotFound if SwapID has no reservation
+	Release(ctx context.Context, swapID uint64) (Reservation, error)
+	// Reservations return wallet reservations, all wallets if wallet is empty
+	Reservations(ctx context.Context, wallet string) ([]Reservation, error)
+}
+
+func sortRecords(records []SwapRecord) []SwapRecord {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].SwapID < records[j].SwapID
+	})
+	return records
+}
+
+// applyTransition check and update record, record.State is SwapStateNone for new swap
+func applyTransition(record SwapRecord, swapID uint64, to SwapState, update UpdateFunc) (SwapRecord, error) {
+	if !record.State.CanTransition(to) {
+		return record, ErrInvalidTransition
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if record.State == SwapStateNone {
+		record.SwapID = swapID
+		record.CreatedAt = now
+	}
+	record.State = to
+	record.UpdatedAt = now
+
+	if update != nil {
+		update(&record)
+	}
+	return record, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2020 Condensat Tech. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/condensat/bank-swap/liquid/common"
+)
+
+func TestSwapState_CanTransition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		from SwapState
+		to   SwapState
+		want bool
+	}{
+		{"propose", SwapStateNone, SwapStateProposed, true},
+		{"accept", SwapStateNone, SwapStateAccepted, true},
+		{"proposedAccepted", SwapStateProposed, SwapStateAccepted, true},
+		{"acceptedFinalized", SwapStateAccepted, SwapStateFinalized, true},
+		{"finalizedBroadcast", SwapStateFinalized, SwapStateBroadcast, true},
+		{"broadcastConfirmed", SwapStateBroadcast, SwapStateConfirmed, true},
+		{"proposedCancelled", SwapStateProposed, SwapStateCancelled, true},
+		{"acceptedExpired", SwapStateAccepted, SwapStateExpired, true},
+
+		{"noneFinalized", SwapStateNone, SwapStateFinalized, false},
+		{"proposedFinalized", SwapStateProposed, SwapStateFinalized, false},
+		{"proposedTwice", SwapStateProposed, SwapStateProposed, false},
+		{"broadcastCancelled", SwapStateBroadcast, SwapStateCancelled, false},
+		{"confirmedFailed", SwapStateConfirmed, SwapStateFailed, false},
+		{"cancelledAccepted", SwapStateCancelled, SwapStateAccepted, false},
+	}
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.from.CanTransition(tt.to); got != tt.want {
+				t.Errorf("SwapState.CanTransition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_Transition(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if _, err := store.Get(ctx, 42); err != ErrSwapNotFound {
+		t.Errorf("MemoryStore.Get() error = %v, want %v", err, ErrSwapNotFound)
+	}
+	if _, err := store.Transition(ctx, 42, SwapStateFinalized, nil); err != ErrInvalidTransition {
+		t.Errorf("MemoryStore.Transition() error = %v, want %v", err, ErrInvalidTransition)
+	}
+
+	created, err := store.Transition(ctx, 42, SwapStateProposed, nil)
+	if err != nil {
+		t.Fatalf("MemoryStore.Transition() error = %v", err)
+	}
+	if created.SwapID != 42 || created.State != SwapStateProposed || created.CreatedAt.IsZero() {
+		t.Errorf("MemoryStore.Transition() wrong record %+v", created)
+	}
+
+	for _, to := range []SwapState{SwapStateAccepted, SwapStateFinalized, SwapStateBroadcast} {
+		_, err := store.Transition(ctx, 42, to, func(record *SwapRecord) {
+			record.TxID = "txid"
+		})
+		if err != nil {
+			t.Fatalf("MemoryStore.Transition(%v) error = %v", to, err)
+		}
+	}
+	if _, err := store.Transition(ctx, 42, SwapStateCancelled, nil); err != ErrInvalidTransition {
+		t.Errorf("MemoryStore.Transition() error = %v, want %v", err, ErrInvalidTransition)
+	}
+
+	record, err := store.Get(ctx, 42)
+	if err != nil {
+		t.Fatalf("MemoryStore.Get() error = %v", err)
+	}
+	if record.State != SwapStateBroadcast || record.TxID != "txid" || !record.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("MemoryStore.Get() wrong record %+v", record)
+	}
+}
+
+func TestMemoryStore_Result(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if _, err := store.Result(ctx, 42, OperationCreate); err != ErrResultNotFound {
+		t.Errorf("MemoryStore.Result() error = %v, want %v", err, ErrResultNotFound)
+	}
+
+	result := OperationResult{
+		Fingerprint: "fingerprint",
+		Result:      common.SwapProposal{SwapID: 42, Payload: "payload"},
+	}
+	if err := store.SaveResult(ctx, 42, OperationCreate, result); err != nil {
+		t.Fatalf("MemoryStore.SaveResult() error = %v", err)
+	}
+
+	got, err := store.Result(ctx, 42, OperationCreate)
+	if err != nil || got.Fingerprint != result.Fingerprint || got.Result.Payload != result.Result.Payload {
+		t.Errorf("MemoryStore.Result() = %+v, %v, want %+v", got, err, result)
+	}
+	if _, err := store.Result(ctx, 42, OperationAccept); err != ErrResultNotFound {
+		t.Errorf("MemoryStore.Result() other operation error = %v, want %v", err, ErrResultNotFound)
+	}
+}
+
+func TestMemoryStore_Reserve(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	reserved := func(txID string, vout int, amount common.Amount) ReservedUnspent {
+		return ReservedUnspent{Outpoint: common.Outpoint{TxID: txID, Vout: vout}, Asset: "asset", Amount: amount}
+	}
+	first := Reservation{SwapID: 1, Wallet: "proposer", Unspents: []ReservedUnspent{reserved("tx1", 0, 10), reserved("tx1", 1, 20)}}
+	conflict := Reservation{SwapID: 2, Wallet: "proposer", Unspents: []ReservedUnspent{reserved("tx2", 0, 5), reserved("tx1", 1, 20)}}
+	other := Reservation{SwapID: 3, Wallet: "treasury", Unspents: []ReservedUnspent{reserved("tx3", 0, 7)}}
+
+	if err := store.Reserve(ctx, first); err != nil {
+		t.Fatalf("MemoryStore.Reserve() error = %v", err)
+	}
+	if err := store.Reserve(ctx, first); err != nil {
+		t.Errorf("MemoryStore.Reserve() same swap error = %v", err)
+	}
+	if err := store.Reserve(ctx, conflict); err != ErrAlreadyReserved {
+		t.Errorf("MemoryStore.Reserve() conflict error = %v, want %v", err, ErrAlreadyReserved)
+	}
+	if err := store.Reserve(ctx, other); err != nil {
+		t.Fatalf("MemoryStore.Reserve() error = %v", err)
+	}
+
+	reservations, err := store.Reservations(ctx, "proposer")
+	if err != nil || len(reservations) != 1 || reservations[0].SwapID != 1 {
+		t.Errorf("MemoryStore.Reservations() = %+v, %v, want swap 1", reservations, err)
+	}
+	reservations, _ = store.Reservations(ctx, "")
+	if amounts := ReservedAmounts(reservations); amounts["asset"] != 37 {
+		t.Errorf("ReservedAmounts() = %v, want %v", amounts["asset"], 37)
+	}
+
+	released, err := store.Release(ctx, 1)
+	if err != nil || len(released.Outpoints()) != 2 {
+		t.Errorf("MemoryStore.Release() = %+v, %v", released, err)
+	}
+	if _, err := store.Release(ctx, 1); err != ErrReservationNotFound {
+		t.Errorf("MemoryStore.Release() error = %v, want %v", err, ErrReservationNotFound)
+	}
+	if err := store.Reserve(ctx, conflict); err != nil {
+		t.Errorf("MemoryStore.Reserve() released error = %v", err)
+	}
+}
+
+func TestMemoryStore_List(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for swapID, states := range map[uint64][]SwapState{
+		3: {SwapStateProposed},
+		2: {SwapStateProposed, SwapStateAccepted, SwapStateFinalized, SwapStateBroadcast},
+		1: {SwapStateProposed, SwapStateAccepted, SwapStateFinalized, SwapStateBroadcast},
+		4: {SwapStateProposed, SwapStateCancelled},
+	} {
+		for _, to := range states {
+			if _, err := store.Transition(ctx, swapID, to, nil); err != nil {
+				t.Fatalf("MemoryStore.Transition() error = %v", err)
+			}
+		}
+	}
+
+	records, err := store.List(ctx, SwapStateBroadcast)
+	if err != nil || len(records) != 2 || records[0].SwapID != 1 || records[1].SwapID != 2 {
+		t.Errorf("MemoryStore.List() = %+v, %v, want swaps 1 and 2", records, err)
+	}
+	if records, _ := store.List(ctx, SwapStateCancelled); len(records) != 0 {
+		t.Errorf("MemoryStore.List() final state = %+v, want none", records)
+	}
+}
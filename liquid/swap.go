@@ -14,21 +14,44 @@ import (
 
 	"github.com/condensat/bank-swap/liquid/common"
 	"github.com/condensat/bank-swap/liquid/handlers"
+	"github.com/condensat/bank-swap/liquid/state"
 
 	"github.com/sirupsen/logrus"
 )
 
 type Swap int
 
-func (p *Swap) Run(ctx context.Context, elementsConf string) {
+func (p *Swap) Run(ctx context.Context, wallets *handlers.WalletRegistry) {
 	log := logger.Logger(ctx).WithField("Method", "Swap.Run")
 
-	handlers.SetElementsConf(elementsConf)
+	if wallets == nil || len(wallets.Wallets()) == 0 {
+		log.WithError(common.ErrBackendNotFound).
+			Panic("Invalid Swap Wallets")
+	}
 
-	p.registerHandlers(cache.RedisMutexContext(ctx))
+	store, err := state.NewRedisStore(ctx)
+	if err != nil {
+		log.WithError(err).
+			Panic("Failed to create swap store")
+	}
+
+	ctx = handlers.SwapWalletsContext(ctx, wallets)
+	ctx = state.SwapStoreContext(ctx, store)
+	ctx = cache.RedisMutexContext(ctx)
+	if handlers.WorkQueueFromContext(ctx) == nil {
+		ctx = handlers.WorkQueueContext(ctx, handlers.NewWorkQueue(handlers.DefaultQueueCapacity))
+	}
+	p.registerHandlers(ctx)
+
+	go handlers.WatchReservations(ctx, handlers.DefaultReservationInterval)
+	go handlers.WatchConfirmations(ctx, handlers.DefaultConfirmationInterval)
 
 	log.WithFields(logrus.Fields{
-		"Hostname": utils.Hostname(),
+		"Hostname":         utils.Hostname(),
+		"Wallets":          wallets.Wallets(),
+		"Network":          common.NetworkFromContext(ctx),
+		"Confirmations":    handlers.SwapConfirmationsFromContext(ctx),
+		"BroadcastTimeout": handlers.BroadcastTimeoutFromContext(ctx),
 	}).Info("Liquid Swap Service started")
 
 	<-ctx.Done()
@@ -38,13 +61,33 @@ func (p *Swap) registerHandlers(ctx context.Context) {
 	log := logger.Logger(ctx).WithField("Method", "Liquid.RegisterHandlers")
 
 	nats := appcontext.Messaging(ctx)
+	network := common.NetworkFromContext(ctx)
 
 	const concurencyLevel = 8
 
-	nats.SubscribeWorkers(ctx, common.SwapCreateProposalSubject, 2*concurencyLevel, handlers.OnCreateSwapProposal)
-	nats.SubscribeWorkers(ctx, common.SwapInfoProposalSubject, 2*concurencyLevel, handlers.OnInfoSwapProposal)
-	nats.SubscribeWorkers(ctx, common.SwapFinalizeProposalSubject, 2*concurencyLevel, handlers.OnFinalizeSwapProposal)
-	nats.SubscribeWorkers(ctx, common.SwapAcceptProposalSubject, 2*concurencyLevel, handlers.OnAcceptSwapProposal)
+	// previous schema versions are served until clients are migrated
+	for _, version := range common.SupportedSchemaVersions {
+		subject := func(subject string) string {
+			return network.Subject(common.SubjectVersion(subject, version))
+		}
+
+		nats.SubscribeWorkers(ctx, subject(common.SwapCreateProposalSubject), 2*concurencyLevel, handlers.OnCreateSwapProposal)
+		nats.SubscribeWorkers(ctx, subject(common.SwapInfoProposalSubject), 2*concurencyLevel, handlers.OnInfoSwapProposal)
+		nats.SubscribeWorkers(ctx, subject(common.SwapFinalizeProposalSubject), 2*concurencyLevel, handlers.OnFinalizeSwapProposal)
+		nats.SubscribeWorkers(ctx, subject(common.SwapAcceptProposalSubject), 2*concurencyLevel, handlers.OnAcceptSwapProposal)
+		nats.SubscribeWorkers(ctx, subject(common.SwapCancelProposalSubject), 2*concurencyLevel, handlers.OnCancelSwapProposal)
+		nats.SubscribeWorkers(ctx, subject(common.SwapBroadcastSubject), 2*concurencyLevel, handlers.OnBroadcastSwapTransaction)
+		nats.SubscribeWorkers(ctx, subject(common.SwapBalancesSubject), concurencyLevel, handlers.OnSwapBalances)
+
+		// each instance report its own work queues
+		if err := handlers.SubscribeSwapQueueStatus(ctx, subject(common.SwapQueueStatusSubject)); err != nil {
+			log.WithError(err).
+				Panic("Failed to subscribe queue status")
+		}
+	}
 
-	log.Debug("Liquid Swap registered")
+	log.WithFields(logrus.Fields{
+		"Network":  network,
+		"Versions": common.SupportedSchemaVersions,
+	}).Debug("Liquid Swap registered")
 }